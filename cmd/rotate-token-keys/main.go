@@ -0,0 +1,40 @@
+// main.go: 保存済みのrefresh_tokenを有効な鍵（GOOGLE_TOKEN_ENC_ACTIVE_KEY_ID）で再暗号化する
+//
+// 鍵のローテーション手順:
+//  1. GOOGLE_TOKEN_ENC_KEYS に新しい鍵を追加し、GOOGLE_TOKEN_ENC_ACTIVE_KEY_ID を新しい鍵IDに変更してデプロイ
+//  2. 本コマンドを実行して既存のトークンを再暗号化
+//  3. 失敗件数が0であることを確認してから古い鍵を GOOGLE_TOKEN_ENC_KEYS から削除
+//
+// TOKEN_ENCRYPTION_MODE=envelope の場合は、既存のトークンをエンベロープ形式（KeyManager の有効なKEK）に移行する。
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"shakehandz-api/internal/auth"
+	config "shakehandz-api/internal/shared"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "再暗号化せず対象件数のみ表示する")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Fatal(".env ファイルの読み込みに失敗しました")
+	}
+
+	db := config.InitDB()
+
+	res, err := auth.ReencryptRefreshTokens(db, *dryRun)
+	if err != nil {
+		log.Fatalf("refresh_tokenの再暗号化に失敗しました: %v", err)
+	}
+	if res.Failed > 0 {
+		log.Printf("%d件のトークンを再暗号化できませんでした。古い鍵を削除しないでください", res.Failed)
+		os.Exit(1)
+	}
+}
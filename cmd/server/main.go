@@ -2,27 +2,35 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
 
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 
 	"shakehandz-api/internal/router"
+	"shakehandz-api/internal/shared/cache"
 )
 
 func main() {
-	// ctx := context.Background()
+	ctx := context.Background()
 
 	if err := godotenv.Load(); err != nil {
 		log.Fatal(".env ファイルの読み込みに失敗しました")
 	}
 
-	// rdb, err := cache.NewRedisClient(ctx)
-	// if err != nil {
-	// 	log.Fatalf("Redisクライアントの初期化に失敗しました: %v", err)
-	// }
+	// REDIS_ADDRが設定されている場合のみRedisを利用する（未設定時はメモリ／DBで代替）
+	var rdb *redis.Client
+	if os.Getenv("REDIS_ADDR") != "" {
+		c, err := cache.NewRedisClient(ctx)
+		if err != nil {
+			log.Fatalf("Redisクライアントの初期化に失敗しました: %v", err)
+		}
+		rdb = c
+	}
 
-	// r := router.SetupRouter(rdb)
-	r := router.SetupRouter(nil)
+	r := router.SetupRouter(rdb)
 
 	if err := r.Run(":8080"); err != nil {
 		log.Fatalf("サーバー起動失敗: %v", err)
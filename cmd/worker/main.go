@@ -0,0 +1,47 @@
+// main.go: 抽出ジョブを処理するワーカー。APIサーバーとは別プロセスで起動する
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/joho/godotenv"
+
+	"shakehandz-api/internal/extractor"
+	config "shakehandz-api/internal/shared"
+	"shakehandz-api/internal/shared/cache"
+	"shakehandz-api/internal/shared/message/gmail"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Fatal(".env ファイルの読み込みに失敗しました")
+	}
+
+	// SIGINT/SIGTERMで実行中のジョブの終了を待って停止する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db := config.InitDB()
+
+	// 進捗・キャンセル状態はRedis未設定時はプロセス内のメモリに保持され、APIサーバーと共有できないため起動しない
+	if os.Getenv("REDIS_ADDR") == "" {
+		log.Fatal("REDIS_ADDR が未設定です。APIサーバーと別プロセスでワーカーを起動するにはRedisが必要です（単一プロセスで動かす場合は EXTRACTOR_INPROCESS_WORKER=true を指定してください）")
+	}
+	rdb, err := cache.NewRedisClient(ctx)
+	if err != nil {
+		log.Fatalf("Redisクライアントの初期化に失敗しました: %v", err)
+	}
+
+	svc := extractor.NewExtractorService(gmail.NewGmailMsgFetcher(), db, rdb)
+
+	if err := svc.RecoverPendingBatches(ctx); err != nil {
+		log.Printf("待機中バッチの再登録に失敗しました: %v", err)
+	}
+
+	cfg := extractor.WorkerConfigFromEnv()
+	svc.NewWorker(cfg.Concurrency).Run(ctx)
+}
@@ -25,7 +25,7 @@ type OauthToken struct {
 	Sub      string  `gorm:"size:191;not null;index:uq_provider_sub,unique"`
 	Scope    *string `gorm:"type:text"`
 
-	// 暗号化済みのrefresh_token。バイナリ格納（AES-GCMのnonce+ct）を推奨
+	// 暗号化済みのrefresh_token。バイナリ格納（鍵ID付きのAES-GCM暗号文。crypto パッケージ参照）
 	RefreshToken []byte `gorm:"type:varbinary(1024);not null"`
 
 	// access_tokenの有効期限を保存したいならnullableで
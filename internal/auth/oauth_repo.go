@@ -2,7 +2,12 @@ package auth
 
 import (
 	"errors"
+	"log"
+	"time"
 
+	"shakehandz-api/internal/shared/retry"
+
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -17,3 +22,41 @@ func FindGoogleRefreshTokenEncByUserID(db *gorm.DB, userID string) ([]byte, erro
 	}
 	return rec.RefreshToken, nil
 }
+
+// FindGoogleTokenByUserID はユーザーのGoogleトークンを取得する（存在しない場合はnil）
+func FindGoogleTokenByUserID(db *gorm.DB, userID uuid.UUID) (*OauthToken, error) {
+	var rec OauthToken
+	err := db.Where("user_id = ? AND provider = ?", userID, "google").First(&rec).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// IsRevoked はrefresh_tokenが失効済みかを返す
+func (t OauthToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// MarkGoogleTokenRevoked はユーザーのGoogleトークンを失効済みにする
+// 再同意（/api/auth/upsert）でトークンが更新されると解除される
+func MarkGoogleTokenRevoked(db *gorm.DB, userID uuid.UUID) error {
+	return db.Model(&OauthToken{}).
+		Where("user_id = ? AND provider = ? AND revoked_at IS NULL", userID, "google").
+		Update("revoked_at", time.Now()).Error
+}
+
+// HandleTokenError はGoogle APIのエラーがrefresh_tokenの失効（invalid_grant）によるものであれば
+// トークンを失効済みにしてtrueを返す
+func HandleTokenError(db *gorm.DB, userID uuid.UUID, err error) bool {
+	if !retry.IsInvalidGrant(err) {
+		return false
+	}
+	if markErr := MarkGoogleTokenRevoked(db, userID); markErr != nil {
+		log.Printf("ERROR: トークンの失効記録に失敗: %v", markErr)
+	}
+	return true
+}
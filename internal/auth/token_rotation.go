@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+
+	"shakehandz-api/internal/shared/crypto"
+
+	"gorm.io/gorm"
+)
+
+// tokenRotationBatchSize は再暗号化時に一度に読み込むトークン数
+const tokenRotationBatchSize = 100
+
+// TokenRotationResult は再暗号化の結果です。
+type TokenRotationResult struct {
+	Total       int
+	Reencrypted int
+	Skipped     int
+	Failed      int
+}
+
+// ReencryptRefreshTokens は有効な鍵以外で暗号化されたrefresh_tokenを有効な鍵で再暗号化する
+// dryRun=true の場合は対象件数の集計のみ行う
+func ReencryptRefreshTokens(db *gorm.DB, dryRun bool) (TokenRotationResult, error) {
+	var res TokenRotationResult
+
+	activeID, err := crypto.ActiveKeyID()
+	if err != nil {
+		return res, fmt.Errorf("active key: %w", err)
+	}
+
+	var batch []OauthToken
+	err = db.Unscoped().Select("id", "user_id", "refresh_token").
+		FindInBatches(&batch, tokenRotationBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, t := range batch {
+				res.Total++
+
+				needs, err := crypto.NeedsReencrypt(t.RefreshToken)
+				if err != nil {
+					return err
+				}
+				if !needs {
+					res.Skipped++
+					continue
+				}
+
+				plain, err := crypto.DecryptFromBytes(t.RefreshToken)
+				if err != nil {
+					log.Printf("トークンの復号に失敗しました (id=%d, user=%s): %v", t.ID, t.UserID, err)
+					res.Failed++
+					continue
+				}
+				if dryRun {
+					res.Reencrypted++
+					continue
+				}
+
+				enc, err := crypto.EncryptToBytes(plain)
+				if err != nil {
+					return fmt.Errorf("encrypt token %d: %w", t.ID, err)
+				}
+				// 読み込み後に更新されたトークンを上書きしないよう、暗号文が変わっていない場合のみ更新する
+				upd := db.Model(&OauthToken{}).Unscoped().
+					Where("id = ? AND refresh_token = ?", t.ID, t.RefreshToken).
+					UpdateColumn("refresh_token", enc)
+				if upd.Error != nil {
+					log.Printf("トークンの更新に失敗しました (id=%d, user=%s): %v", t.ID, t.UserID, upd.Error)
+					res.Failed++
+					continue
+				}
+				if upd.RowsAffected == 0 {
+					// 並行して更新済み（新しい暗号文は有効な鍵で暗号化されている）
+					res.Skipped++
+					continue
+				}
+				res.Reencrypted++
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return res, err
+	}
+
+	log.Printf("refresh_tokenの再暗号化: 有効な鍵=%s 対象=%d 再暗号化=%d スキップ=%d 失敗=%d (dry-run=%v)",
+		activeID, res.Total, res.Reencrypted, res.Skipped, res.Failed, dryRun)
+	return res, nil
+}
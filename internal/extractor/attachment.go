@@ -0,0 +1,43 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"shakehandz-api/internal/shared/attachment"
+	msg "shakehandz-api/internal/shared/message"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// attachAttachmentTexts は添付ファイル（スキルシート等）をダウンロードしてテキストを抽出し、メッセージに設定する
+// 抽出に失敗した添付ファイルはスキップし、本文のみで解析を続ける
+func (s *Service) attachAttachmentTexts(ctx context.Context, gmail_svc *gmail.Service, msgs []*msg.Message) {
+	for _, m := range msgs {
+		for i := range m.Attachments {
+			att := &m.Attachments[i]
+
+			if err := s.Attachment.Check(att.Filename, att.Size); err != nil {
+				if !errors.Is(err, attachment.ErrUnsupported) {
+					fmt.Printf("添付ファイルをスキップ (message_id: %s, file: %s): %v\n", m.Id, att.Filename, err)
+				}
+				continue
+			}
+
+			data, err := s.Fetcher.FetchAttachment(ctx, gmail_svc, m.Id, att.AttachmentID)
+			if err != nil {
+				log.Printf("添付ファイルの取得に失敗 (message_id: %s, file: %s): %v", m.Id, att.Filename, err)
+				continue
+			}
+
+			text, err := s.Attachment.ExtractText(att.Filename, data)
+			if err != nil {
+				log.Printf("添付ファイルのテキスト抽出に失敗 (message_id: %s, file: %s): %v", m.Id, att.Filename, err)
+				continue
+			}
+			att.Text = text
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CancelBatches はユーザー・抽出種別の待機中ジョブを取り消し、実行中のバッチをキャンセルする
+// 実行中のジョブは watchCancellation がキャンセルを検知して中断する。取り消したバッチ数を返す
+func (s *Service) CancelBatches(ctx context.Context, userID uuid.UUID, extractorType string) (int, error) {
+	cancelled, err := s.cancelBatches(ctx, userID, extractorType)
+	if err != nil {
+		return 0, err
+	}
+
+	// 実行中のジョブがキャンセル前の確認を通過して次回のバッチを登録していた場合に備え、
+	// バッチをキャンセルした後にもう一度取り消す（scheduleNextBatch も登録後に再確認する）
+	again, err := s.cancelBatches(ctx, userID, extractorType)
+	if err != nil {
+		return cancelled, err
+	}
+	return cancelled + again, nil
+}
+
+func (s *Service) cancelBatches(ctx context.Context, userID uuid.UUID, extractorType string) (int, error) {
+	if _, err := s.Queue.CancelPending(ctx, ExtractionQueue, extractionJobKey(userID, extractorType)); err != nil {
+		return 0, fmt.Errorf("ジョブの取り消しに失敗しました: %w", err)
+	}
+
+	var batches []ExtractorBatchExecution
+	if err := s.DB.WithContext(ctx).
+		Where("user_id = ? AND extractor_type = ? AND status IN ?", userID, extractorType, []string{StatusPending, StatusInProgress}).
+		Find(&batches).Error; err != nil {
+		return 0, err
+	}
+
+	for _, batch := range batches {
+		s.setBatchStatus(ctx, batch, StatusCancelled, "バッチがキャンセルされました", nil)
+	}
+	return len(batches), nil
+}
+
+// watchCancellation はバッチがキャンセルされたらcancelを呼び出す。ctxが終了するまで監視する
+// ワーカーが別プロセスでも検知できるよう、バッチレコードのステータスを定期的に確認する
+func (s *Service) watchCancellation(ctx context.Context, batchID uint, cancel context.CancelFunc) {
+	ticker := time.NewTicker(JobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.isBatchCancelled(batchID) {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (s *Service) isBatchCancelled(batchID uint) bool {
+	var batch ExtractorBatchExecution
+	if err := s.DB.Select("id", "status").First(&batch, batchID).Error; err != nil {
+		return false
+	}
+	return batch.Status == StatusCancelled
+}
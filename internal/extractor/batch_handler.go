@@ -0,0 +1,133 @@
+package extractor
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ExtractorBatchListResponse struct {
+	Pagination response.PaginationInfo   `json:"pagination"`
+	Batches    []ExtractorBatchExecution `json:"batches"`
+}
+
+type CancelExtractionResponse struct {
+	Cancelled int `json:"cancelled"`
+}
+
+// GET /structure/batches
+// バッチの実行履歴を新しい順に取得する（extractor_type, statusで絞り込み可）
+func ListExtractorBatchesHandler(svc *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor batch",
+			})
+			return
+		}
+
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if page <= 0 {
+			page = 1
+		}
+		if limit <= 0 || limit > 100 {
+			limit = 20
+		}
+
+		query := svc.DB.Model(&ExtractorBatchExecution{}).Where("user_id = ?", user.ID)
+		if extractorType := c.Query("extractor_type"); extractorType != "" {
+			query = query.Where("extractor_type = ?", extractorType)
+		}
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor batch",
+			})
+			return
+		}
+
+		var batches []ExtractorBatchExecution
+		if err := query.Order("execution_date DESC").Offset((page - 1) * limit).Limit(limit).Find(&batches).Error; err != nil {
+			response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor batch",
+			})
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, ExtractorBatchListResponse{
+			Pagination: response.NewPaginationInfo(page, limit, total),
+			Batches:    batches,
+		})
+	}
+}
+
+// GET /structure/batches/:id
+func GetExtractorBatchHandler(svc *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor batch",
+			})
+			return
+		}
+
+		var batch ExtractorBatchExecution
+		if err := svc.DB.Where("user_id = ?", user.ID).First(&batch, "id = ?", c.Param("id")).Error; err != nil {
+			code := apierror.Common.DatabaseError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = apierror.Extractor.BatchNotFound
+			}
+			response.SendError(c, code, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor batch",
+			})
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, batch)
+	}
+}
+
+// POST /structure/{humanresource|project}/cancel
+// 実行中・待機中のバッチをキャンセルし、自動実行のループを停止する
+func CancelExtractionHandler(svc *Service, extractorType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor batch",
+			})
+			return
+		}
+
+		cancelled, err := svc.CancelBatches(c.Request.Context(), user.ID, extractorType)
+		if err != nil {
+			response.SendError(c, apierror.Extractor.Unknown, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor batch",
+			})
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, CancelExtractionResponse{Cancelled: cancelled})
+	}
+}
@@ -2,6 +2,8 @@ package extractor
 
 import "time"
 
+// MaxMessages, GeminiChunkSize, MaxGoroutine, PageSize, MaxPages, 検索クエリ, GeminiModel,
+// MaxExecutionDuration, MessageTTL はユーザーごとの抽出設定（ExtractorSetting）のデフォルト値
 const (
 	// バッチ処理ステータス
 	// 1回のバッチで処理する最大メール件数
@@ -14,12 +16,18 @@ const (
 	MaxExecutionDuration = 10 * time.Minute
 	// バッチ失敗時の自動復旧可能数
 	MaxRetryCount = 3
+	// 同一メールの解析失敗を許容する回数（超えたメールは再requeueまで取得対象外）
+	MaxFailureAttempts = 3
 
 	// Gmail API関連定数
 	// Gmailの1ページあたりの取得件数
 	PageSize = 50
 	// Gmailの最大ページ数（PageSize x MaxPages 件まで取得）
 	MaxPages = 10
+	// 要員メールの検索クエリ（スキルシート添付）
+	HumanResourceQuery = "has:attachment"
+	// 案件メールの検索クエリ
+	ProjectQuery = "subject:(案件 OR 募集)"
 
 	// Geminiモデル
 	GeminiModel = "models/gemini-2.5-flash"
@@ -29,3 +37,20 @@ const (
 	// MessageTTL = 5 * 24 * time.Hour // 5日
 	MessageTTL = 30 * time.Minute // 30分
 )
+
+// ジョブキュー
+const (
+	ExtractionQueue      = "extraction"    // 抽出ジョブのキュー名
+	JobVisibilityTimeout = 5 * time.Minute // ハートビートが途絶えたジョブを再配布するまでの時間
+	JobPollInterval      = 5 * time.Second // キューが空のときの待機時間
+	JobWorkerConcurrency = 3               // ワーカーの同時実行数
+)
+
+// レート制限
+const (
+	// ユーザーごとのGemini呼び出し回数の上限（1分あたり）と連続呼び出し数（GEMINI_RATE_PER_MINUTE, GEMINI_RATE_BURSTで変更可）
+	GeminiRatePerMinute = 60
+	GeminiRateBurst     = 3
+	// 割り当て超過時にジョブを再実行するまでの待機時間
+	QuotaRetryDelay = 1 * time.Hour
+)
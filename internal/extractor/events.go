@@ -0,0 +1,61 @@
+package extractor
+
+import (
+	"context"
+	"log"
+	"time"
+
+	cache_extractor "shakehandz-api/internal/shared/cache/extractor"
+
+	"github.com/google/uuid"
+)
+
+// 進捗イベントの種別
+const (
+	EventBatchQueued     = "batch_queued"
+	EventBatchStarted    = "batch_started"
+	EventPageFetched     = "page_fetched"
+	EventMessagesFetched = "messages_fetched"
+	EventChunkSent       = "chunk_sent"
+	EventRecordsSaved    = "records_saved"
+	EventSkillsUpdated   = "skills_updated"
+	EventBatchCompleted  = "batch_completed"
+	EventBatchNoData     = "batch_no_data"
+	EventBatchFailed     = "batch_failed"
+	EventBatchExpired    = "batch_expired"
+	EventBatchCancelled  = "batch_cancelled"
+)
+
+// バッチのステータスと、変更時に配信するイベントの対応
+var batchStatusEvents = map[string]string{
+	StatusPending:    EventBatchQueued,
+	StatusInProgress: EventBatchStarted,
+	StatusCompleted:  EventBatchCompleted,
+	StatusNoData:     EventBatchNoData,
+	StatusFailed:     EventBatchFailed,
+	StatusExpired:    EventBatchExpired,
+	StatusCancelled:  EventBatchCancelled,
+}
+
+// publish はユーザー・抽出種別のチャネルにイベントを配信する。失敗しても処理は継続する
+func (s *Service) publish(ctx context.Context, userID uuid.UUID, extractorType string, ev cache_extractor.Event) {
+	ev.ExtractorType = extractorType
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	if err := s.Events.Publish(ctx, cache_extractor.StatusID(userID, extractorType), ev); err != nil {
+		log.Printf("ERROR: イベントの配信に失敗: %v", err)
+	}
+}
+
+// progress は進捗ステータスを更新し、更新後の進捗を添えてイベントを配信する
+func (s *Service) progress(ctx context.Context, batch ExtractorBatchExecution, evType, message string, count int, update func(js *cache_extractor.JobStatus)) {
+	snapshot := s.updateStatus(ctx, batch, update)
+	s.publish(ctx, batch.UserID, batch.ExtractorType, cache_extractor.Event{
+		Type:    evType,
+		BatchID: batch.ID,
+		Message: message,
+		Count:   count,
+		Status:  snapshot,
+	})
+}
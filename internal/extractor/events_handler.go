@@ -0,0 +1,73 @@
+package extractor
+
+import (
+	"io"
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	cache_extractor "shakehandz-api/internal/shared/cache/extractor"
+	"shakehandz-api/internal/shared/response"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 接続維持のためのコメント送信間隔
+const sseKeepAliveInterval = 15 * time.Second
+
+// ExtractionEventsHandler は抽出処理の進捗イベントをServer-Sent Eventsで配信する
+// 接続直後に現在の進捗を "status" イベントとして送り、以降はイベント種別ごとに配信する
+func ExtractionEventsHandler(svc *Service, extractorType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extraction events",
+			})
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		events, unsubscribe, err := svc.Events.Subscribe(ctx, cache_extractor.StatusID(user.ID, extractorType))
+		if err != nil {
+			response.SendError(c, apierror.Extractor.Unknown, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extraction events",
+			})
+			return
+		}
+		defer unsubscribe()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+
+		if status, err := svc.GetStatus(ctx, user.ID, extractorType); err == nil {
+			c.SSEvent("status", status)
+			c.Writer.Flush()
+		}
+
+		keepAlive := time.NewTicker(sseKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case ev, ok := <-events:
+				if !ok {
+					return false
+				}
+				c.SSEvent(ev.Type, ev)
+				return true
+			case <-keepAlive.C:
+				if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+					return false
+				}
+				return true
+			}
+		})
+	}
+}
@@ -2,28 +2,26 @@ package extractor
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"shakehandz-api/internal/auth"
 	"shakehandz-api/internal/humanresource"
-	"shakehandz-api/internal/shared/llm/gemini"
+	cache_extractor "shakehandz-api/internal/shared/cache/extractor"
+	"shakehandz-api/internal/shared/llm"
 	"shakehandz-api/internal/shared/options"
 	"shakehandz-api/prompts"
-	"strings"
 	"sync"
 
-	"github.com/google/generative-ai-go/genai"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 	"google.golang.org/api/gmail/v1"
 )
 
-func Extract(ctx context.Context, user auth.User, client *gemini.Client, gmail_svc *gmail.Service, s *Service, currentBatch ExtractorBatchExecution) (bool, error) {
+func Extract(ctx context.Context, user auth.User, client llm.Provider, gmail_svc *gmail.Service, s *Service, currentBatch ExtractorBatchExecution, setting ExtractorSetting) (bool, error) {
 	fmt.Println("kmoaiはGmailを取得中")
 
 	// DB既存のメッセージIDを除外した未処理メッセージを最大N件取得
-	msgs, err := s.fetchUnprocessedMessages(ctx, user, gmail_svc, MaxMessages)
+	msgs, err := s.fetchUnprocessedMessages(ctx, user, gmail_svc, setting)
 	if err != nil {
 		fmt.Println("fetchUnprocessedMessages error:", err)
 		return false, err
@@ -38,114 +36,104 @@ func Extract(ctx context.Context, user auth.User, client *gemini.Client, gmail_s
 	fmt.Println("Gmail取得を完了。今回の解析件数は", len(msgs), "件です。kmoaiにプロンプトを送信中")
 
 	// chunkArrayで分割（JSON文字列の配列として）
-	chunkedMsgs := chunkArray(msgs, GeminiChunkSize)
+	s.progress(ctx, currentBatch, EventMessagesFetched, "メールを取得しました", len(msgs), func(js *cache_extractor.JobStatus) {
+		js.MessagesFetched = len(msgs)
+	})
+
+	// 添付ファイルのテキストをメッセージに追加
+	s.attachAttachmentTexts(ctx, gmail_svc, msgs)
+
+	chunkedMsgs := chunkArray(msgs, setting.ChunkSize)
 
-	// 共有モデルの浅いコピーを作成してSystemInstructionを一度だけ設定
-	localModel := client.Model
-	localModel.SystemInstruction = &genai.Content{
-		Role:  "system",
-		Parts: []genai.Part{genai.Text(prompts.HRInstruction)},
-	}
 	fmt.Println("kmoaiは準備完了。続いて変換処理へ移行")
 
-	g, ctx := errgroup.WithContext(ctx)
+	g, gctx := errgroup.WithContext(ctx)
 	var mu sync.Mutex
-	sem := semaphore.NewWeighted(MaxGoroutine)
+	sem := semaphore.NewWeighted(int64(setting.Concurrency))
 
 	// 最終結果を格納するスライス
 	var humanResources []humanresource.HumanResource
+	// 検証・保存に失敗し除外した抽出結果
+	var rejected []RejectedRecord
+	// LLMでの解析と保存を終えたチャンクのメッセージID
+	var sentIDs []string
+	var acquireErr error
 
-	// SystemInstruction設定済みのローカルモデルを各ゴルーチンで使用
 	for _, cmsg := range chunkedMsgs {
 		chunk := cmsg
-		if len(chunk) == 0 {
+		if len(chunk.IDs) == 0 {
 			continue
 		}
 
-		if err := sem.Acquire(ctx, 1); err != nil {
+		// 取得に失敗した場合も、実行中のチャンクの結果を記録するため終了を待つ
+		if err := sem.Acquire(gctx, 1); err != nil {
 			log.Printf("セマフォの取得に失敗: %v", err)
-			return false, fmt.Errorf("セマフォの取得に失敗: %w", err)
+			acquireErr = fmt.Errorf("セマフォの取得に失敗: %w", err)
+			break
 		}
 
 		g.Go(func() error {
 			defer sem.Release(1)
 
-			// 事前設定されたローカルモデルでGenerateContentを呼び出し
-			geminiResponse, geminiResErr := localModel.GenerateContent(ctx, genai.Text(chunk))
-			if geminiResErr != nil {
-				log.Printf("Gemini API 呼び出し失敗: %v", geminiResErr)
-				return fmt.Errorf("Gemini API 呼び出し失敗: %w", geminiResErr)
-			}
-
-			if geminiResponse == nil {
-				log.Printf("Gemini レスポンスが nil です")
-				return fmt.Errorf("Gemini レスポンスが nil です")
-			}
-
-			// Geminiのレスポンスから文字列を抽出
-			geminiResponsePart, ok := gemini.ExtractText(geminiResponse)
-			if !ok {
-				log.Printf("Gemini レスポンスデータの文字列変換不正: %v", geminiResponsePart)
-				return fmt.Errorf("Gemini レスポンスデータの文字列変換不正: %s", geminiResponsePart)
-			}
-
-			// Geminiのレスポンスから前後の不要な文字列をトリム
-			trimmedResponse := gemini.TrimPrefixAndSuffixGeminiResponse(geminiResponsePart)
-
-			ChunkHumanResources := []humanresource.HumanResource{}
-
-			if err := json.Unmarshal([]byte(trimmedResponse), &ChunkHumanResources); err != nil {
-				return fmt.Errorf("JSON Unmarshal失敗: %w", err)
-			}
-
-			// 念の為、MessageIDの重複を除外
-			seen := make(map[string]struct{}, len(ChunkHumanResources))
-			uniq := make([]humanresource.HumanResource, 0, len(ChunkHumanResources))
-
-			for _, hr := range ChunkHumanResources {
-				mid := strings.TrimSpace(hr.MessageID)
-				if mid == "" {
-					uniq = append(uniq, hr)
-					continue
-				}
-				if _, ok := seen[mid]; ok {
-					continue
-				}
-				seen[mid] = struct{}{}
-				uniq = append(uniq, hr)
+			// LLMでメールを構造化
+			llmResponse, llmErr := client.GenerateJSON(gctx, llm.Request{
+				SystemPrompt: prompts.HRInstruction,
+				Input:        chunk.JSON,
+				Schema:       humanResourceSchema,
+			})
+			if llmErr != nil {
+				log.Printf("LLM 呼び出し失敗: %v", llmErr)
+				return fmt.Errorf("LLM 呼び出し失敗: %w", llmErr)
 			}
+			s.progress(gctx, currentBatch, EventChunkSent, "LLMで解析しました", len(chunk.IDs), func(js *cache_extractor.JobStatus) {
+				js.ChunksSent++
+			})
 
-			ChunkHumanResources = uniq
+			// 要素ごとにデコード・検証し、不正なものは除外して残りを保存する
+			ChunkHumanResources, chunkRejected := decodeRecords(llmResponse, chunk, humanResourceMessageID, (*humanresource.HumanResource).Validate)
 
 			// DB保存
 			fmt.Println("変換完了。kmoaiは", len(ChunkHumanResources), "件の変換を保存中")
-			saved, err := SaveExtractedHumanResources(ChunkHumanResources, user, s)
-
-			if err != nil {
-				return fmt.Errorf("データベースの保存に失敗 次の項目へ進む: %w", err)
-			}
-
-			if saved {
-				fmt.Printf("kmoaiは%d件の変換を保存しました\n", len(ChunkHumanResources))
-
+			saved, saveRejected := SaveExtractedHumanResources(ChunkHumanResources, user, currentBatch.ID, s)
+			s.progress(gctx, currentBatch, EventRecordsSaved, "抽出結果を保存しました", len(saved), func(js *cache_extractor.JobStatus) {
+				js.RecordsSaved += len(saved)
+				js.RecordsRejected += len(chunkRejected) + len(saveRejected)
+			})
+
+			if len(saved) > 0 {
+				fmt.Printf("kmoaiは%d件の変換を保存しました\n", len(saved))
 			}
 
-			for _, hr := range ChunkHumanResources {
-				mu.Lock()
-				humanResources = append(humanResources, hr)
-				mu.Unlock()
-			}
+			mu.Lock()
+			humanResources = append(humanResources, saved...)
+			sentIDs = append(sentIDs, chunk.IDs...)
+			rejected = append(rejected, chunkRejected...)
+			rejected = append(rejected, saveRejected...)
+			mu.Unlock()
 
 			return nil
 		})
 	}
 
-	if err := g.Wait(); err != nil {
+	waitErr := g.Wait()
+	if waitErr == nil {
+		waitErr = acquireErr
+	}
+
+	savedIDs := make([]string, 0, len(humanResources))
+	for _, hr := range humanResources {
+		savedIDs = append(savedIDs, hr.MessageID)
+	}
+
+	if err := waitErr; err != nil {
 		log.Printf("ERROR: 並列処理中にエラー発生: %v", err)
+		// 完了したチャンクの結果は記録し、除外したメールを再試行のたびに再送しないようにする
+		s.recordChunkResults(user, TypeHumanResource, currentBatch.ID, sentIDs, savedIDs, rejected)
 		return false, err
 	}
 
-	fmt.Println("kmoaiは全ての変換を完了しました。総件数：", len(humanResources), "件です。最後の整形を行なっています")
+	s.recordChunkResults(user, TypeHumanResource, currentBatch.ID, sentIDs, savedIDs, rejected)
+	fmt.Println("kmoaiは全ての変換を完了しました。総件数：", len(humanResources), "件（除外：", len(rejected), "件）です。最後の整形を行なっています")
 
 	// 登録されたすべてのスキルをまとめる
 	var allSkills []string
@@ -166,6 +154,11 @@ func Extract(ctx context.Context, user auth.User, client *gemini.Client, gmail_s
 			log.Printf("ERROR: Failed to save skills: %v", err)
 			return false, err
 		}
+		s.progress(ctx, currentBatch, EventSkillsUpdated, "スキル一覧を更新しました", len(allSkills), func(js *cache_extractor.JobStatus) {})
 	}
 	return true, nil
 }
+
+func humanResourceMessageID(hr *humanresource.HumanResource) string {
+	return hr.MessageID
+}
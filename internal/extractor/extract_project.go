@@ -0,0 +1,232 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/project"
+	cache_extractor "shakehandz-api/internal/shared/cache/extractor"
+	"shakehandz-api/internal/shared/llm"
+	"shakehandz-api/internal/shared/options"
+	"shakehandz-api/prompts"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+	"google.golang.org/api/gmail/v1"
+)
+
+// ExtractedProject はGeminiが返却する案件情報。日付は文字列で返却されるため保存前にProjectへ変換する
+type ExtractedProject struct {
+	MessageID                string   `json:"message_id"`
+	EmailSubject             *string  `json:"email_subject"`
+	EmailSender              *string  `json:"email_sender"`
+	EmailReceivedAt          *string  `json:"email_received_at"`
+	ProjectStartMonth        *string  `json:"project_start_month"`
+	Prefecture               *string  `json:"prefecture"`
+	WorkLocation             *string  `json:"work_location"`
+	RemoteWorkFrequency      *string  `json:"remote_work_frequency"`
+	WorkingHours             *string  `json:"working_hours"`
+	RequiredSkills           []string `json:"required_skills"`
+	NiceToHaveSkills         []string `json:"nice_to_have_skills"`
+	UnitPriceMin             *uint    `json:"unit_price_min"`
+	UnitPriceMax             *uint    `json:"unit_price_max"`
+	UnitPriceUnit            *string  `json:"unit_price_unit"`
+	BusinessFlow             *string  `json:"business_flow"`
+	BusinessFlowRestrictions *string  `json:"business_flow_restrictions"`
+	PriorityTalent           *string  `json:"priority_talent"`
+	ProjectSummary           *string  `json:"project_summary"`
+	ExtractionConfidence     *float64 `json:"extraction_confidence"`
+	ExtractionNotes          *string  `json:"extraction_notes"`
+}
+
+// 案件メールの日付として受け付けるフォーマット
+var projectDateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006-01",
+	time.RFC3339,
+}
+
+func parseProjectDate(value *string) *time.Time {
+	if value == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*value)
+	for _, layout := range projectDateLayouts {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// ToProject はGeminiの抽出結果を保存用のProjectに変換する
+func (ep ExtractedProject) ToProject() project.Project {
+	now := time.Now()
+	return project.Project{
+		EmailID:                  strings.TrimSpace(ep.MessageID),
+		EmailSubject:             ep.EmailSubject,
+		EmailSender:              ep.EmailSender,
+		EmailReceivedAt:          parseProjectDate(ep.EmailReceivedAt),
+		ProjectStartMonth:        parseProjectDate(ep.ProjectStartMonth),
+		Prefecture:               ep.Prefecture,
+		WorkLocation:             ep.WorkLocation,
+		RemoteWorkFrequency:      ep.RemoteWorkFrequency,
+		WorkingHours:             ep.WorkingHours,
+		RequiredSkills:           ep.RequiredSkills,
+		NiceToHaveSkills:         ep.NiceToHaveSkills,
+		UnitPriceMin:             ep.UnitPriceMin,
+		UnitPriceMax:             ep.UnitPriceMax,
+		UnitPriceUnit:            ep.UnitPriceUnit,
+		BusinessFlow:             ep.BusinessFlow,
+		BusinessFlowRestrictions: ep.BusinessFlowRestrictions,
+		PriorityTalent:           ep.PriorityTalent,
+		ProjectSummary:           ep.ProjectSummary,
+		RegisteredAt:             &now,
+		ExtractionConfidence:     ep.ExtractionConfidence,
+		ExtractionNotes:          ep.ExtractionNotes,
+	}
+}
+
+func extractedProjectMessageID(ep *ExtractedProject) string {
+	return ep.MessageID
+}
+
+// validate は保存用のProjectに変換した上で検証する
+func (ep *ExtractedProject) validate() error {
+	p := ep.ToProject()
+	return p.Validate()
+}
+
+// ExtractProjects は案件メールをGeminiで構造化し、Projectとして保存する
+func ExtractProjects(ctx context.Context, user auth.User, client llm.Provider, gmail_svc *gmail.Service, s *Service, currentBatch ExtractorBatchExecution, setting ExtractorSetting) (bool, error) {
+	fmt.Println("kmoaiは案件メールを取得中")
+
+	// DB既存のメッセージIDを除外した未処理メッセージを最大N件取得
+	msgs, err := s.fetchUnprocessedMessages(ctx, user, gmail_svc, setting)
+	if err != nil {
+		fmt.Println("fetchUnprocessedMessages error:", err)
+		return false, err
+	}
+
+	if len(msgs) == 0 {
+		fmt.Println("最新の案件メールはすべて処理済みです")
+		return false, nil
+	}
+
+	fmt.Println("案件メール取得を完了。今回の解析件数は", len(msgs), "件です。kmoaiにプロンプトを送信中")
+
+	s.progress(ctx, currentBatch, EventMessagesFetched, "メールを取得しました", len(msgs), func(js *cache_extractor.JobStatus) {
+		js.MessagesFetched = len(msgs)
+	})
+
+	// 添付ファイルのテキストをメッセージに追加
+	s.attachAttachmentTexts(ctx, gmail_svc, msgs)
+
+	chunkedMsgs := chunkArray(msgs, setting.ChunkSize)
+
+	g, gctx := errgroup.WithContext(ctx)
+	var mu sync.Mutex
+	sem := semaphore.NewWeighted(int64(setting.Concurrency))
+
+	var projects []project.Project
+	var rejected []RejectedRecord
+	// LLMでの解析と保存を終えたチャンクのメッセージID
+	var sentIDs []string
+	var acquireErr error
+
+	for _, cmsg := range chunkedMsgs {
+		chunk := cmsg
+		if len(chunk.IDs) == 0 {
+			continue
+		}
+
+		// 取得に失敗した場合も、実行中のチャンクの結果を記録するため終了を待つ
+		if err := sem.Acquire(gctx, 1); err != nil {
+			log.Printf("セマフォの取得に失敗: %v", err)
+			acquireErr = fmt.Errorf("セマフォの取得に失敗: %w", err)
+			break
+		}
+
+		g.Go(func() error {
+			defer sem.Release(1)
+
+			llmResponse, llmErr := client.GenerateJSON(gctx, llm.Request{
+				SystemPrompt: prompts.ProjectInstruction,
+				Input:        chunk.JSON,
+				Schema:       projectSchema,
+			})
+			if llmErr != nil {
+				log.Printf("LLM 呼び出し失敗: %v", llmErr)
+				return fmt.Errorf("LLM 呼び出し失敗: %w", llmErr)
+			}
+			s.progress(gctx, currentBatch, EventChunkSent, "LLMで解析しました", len(chunk.IDs), func(js *cache_extractor.JobStatus) {
+				js.ChunksSent++
+			})
+
+			// 要素ごとにデコード・検証し、不正なものは除外して残りを保存する
+			extracted, chunkRejected := decodeRecords(llmResponse, chunk, extractedProjectMessageID, (*ExtractedProject).validate)
+
+			chunkProjects := make([]project.Project, 0, len(extracted))
+			for _, ep := range extracted {
+				chunkProjects = append(chunkProjects, ep.ToProject())
+			}
+
+			fmt.Println("変換完了。kmoaiは", len(chunkProjects), "件の案件を保存中")
+			saved, saveRejected := SaveExtractedProjects(chunkProjects, user, currentBatch.ID, s)
+			s.progress(gctx, currentBatch, EventRecordsSaved, "抽出結果を保存しました", len(saved), func(js *cache_extractor.JobStatus) {
+				js.RecordsSaved += len(saved)
+				js.RecordsRejected += len(chunkRejected) + len(saveRejected)
+			})
+
+			mu.Lock()
+			projects = append(projects, saved...)
+			sentIDs = append(sentIDs, chunk.IDs...)
+			rejected = append(rejected, chunkRejected...)
+			rejected = append(rejected, saveRejected...)
+			mu.Unlock()
+
+			return nil
+		})
+	}
+
+	waitErr := g.Wait()
+	if waitErr == nil {
+		waitErr = acquireErr
+	}
+
+	savedIDs := make([]string, 0, len(projects))
+	for _, p := range projects {
+		savedIDs = append(savedIDs, p.EmailID)
+	}
+
+	if err := waitErr; err != nil {
+		log.Printf("ERROR: 並列処理中にエラー発生: %v", err)
+		// 完了したチャンクの結果は記録し、除外したメールを再試行のたびに再送しないようにする
+		s.recordChunkResults(user, TypeProject, currentBatch.ID, sentIDs, savedIDs, rejected)
+		return false, err
+	}
+
+	s.recordChunkResults(user, TypeProject, currentBatch.ID, sentIDs, savedIDs, rejected)
+	fmt.Println("kmoaiは全ての案件の変換を完了しました。総件数：", len(projects), "件（除外：", len(rejected), "件）です")
+
+	// 登録されたすべてのスキルをまとめる
+	var allSkills []string
+	for _, p := range projects {
+		allSkills = append(allSkills, p.Skills()...)
+	}
+
+	// 登場スキルを保存
+	if len(allSkills) > 0 {
+		if err := options.SaveSkills(s.DB, allSkills); err != nil {
+			log.Printf("ERROR: Failed to save skills: %v", err)
+			return false, err
+		}
+		s.progress(ctx, currentBatch, EventSkillsUpdated, "スキル一覧を更新しました", len(allSkills), func(js *cache_extractor.JobStatus) {})
+	}
+	return true, nil
+}
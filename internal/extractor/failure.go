@@ -0,0 +1,93 @@
+package extractor
+
+import (
+	"fmt"
+	"time"
+
+	"shakehandz-api/internal/auth"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExtractionFailure は解析・検証・保存に失敗したメールの記録
+// 失敗回数が MaxFailureAttempts に達したメールは以降の取得対象から除外する
+type ExtractionFailure struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:uq_failure_user_type_message" json:"user_id"`
+	ExtractorType string    `gorm:"type:varchar(20);not null;uniqueIndex:uq_failure_user_type_message" json:"extractor_type"`
+	MessageID     string    `gorm:"type:varchar(255);not null;uniqueIndex:uq_failure_user_type_message" json:"message_id"`
+
+	// 最後に失敗したバッチ
+	BatchID       uint      `gorm:"index" json:"batch_id"`
+	ErrorClass    string    `gorm:"type:varchar(30);not null;index" json:"error_class"` // llm_parse, decode, validation, unknown_message_id, db_save
+	ErrorDetail   string    `gorm:"type:text" json:"error_detail"`
+	RawOutput     string    `gorm:"type:mediumtext" json:"raw_output"`
+	AttemptCount  int       `gorm:"not null;default:0" json:"attempt_count"`
+	LastAttemptAt time.Time `gorm:"type:datetime(3)" json:"last_attempt_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// recordFailures は除外した抽出結果を失敗として記録し、既存の記録があれば失敗回数を加算する
+func (s *Service) recordFailures(user auth.User, extractorType string, batchID uint, rejected []RejectedRecord) error {
+	now := time.Now()
+
+	for _, r := range rejected {
+		// message_idが特定できない要素はメールに紐付けられないため記録しない
+		if r.MessageID == "" {
+			continue
+		}
+
+		failure := ExtractionFailure{
+			UserID:        user.ID,
+			ExtractorType: extractorType,
+			MessageID:     r.MessageID,
+			BatchID:       batchID,
+			ErrorClass:    r.Class,
+			ErrorDetail:   r.Reason,
+			RawOutput:     r.Raw,
+			AttemptCount:  1,
+			LastAttemptAt: now,
+		}
+
+		err := s.DB.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"batch_id":        batchID,
+				"error_class":     r.Class,
+				"error_detail":    r.Reason,
+				"raw_output":      r.Raw,
+				"attempt_count":   gorm.Expr("attempt_count + 1"),
+				"last_attempt_at": now,
+				"updated_at":      now,
+			}),
+		}).Create(&failure).Error
+		if err != nil {
+			return fmt.Errorf("失敗記録の保存に失敗 (message_id: %s): %w", r.MessageID, err)
+		}
+	}
+	return nil
+}
+
+// clearFailures は保存に成功したメールの失敗記録を削除する
+func (s *Service) clearFailures(user auth.User, extractorType string, messageIDs []string) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	return s.DB.
+		Where("user_id = ? AND extractor_type = ? AND message_id IN ?", user.ID, extractorType, messageIDs).
+		Delete(&ExtractionFailure{}).Error
+}
+
+// exhaustedFailureIDs は失敗回数が上限に達したメッセージIDを取得する
+func (s *Service) exhaustedFailureIDs(user auth.User, extractorType string, messageIDs []string) ([]string, error) {
+	var ids []string
+	err := s.DB.Model(&ExtractionFailure{}).
+		Where("user_id = ? AND extractor_type = ?", user.ID, extractorType).
+		Where("message_id IN ?", messageIDs).
+		Where("attempt_count >= ?", MaxFailureAttempts).
+		Pluck("message_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,118 @@
+package extractor
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ExtractionFailureResponse struct {
+	Pagination response.PaginationInfo `json:"pagination"`
+	Failures   []ExtractionFailure     `json:"failures"`
+}
+
+// GET /structure/failures
+// 解析に失敗したメールの一覧を取得する（extractor_typeで絞り込み可）
+func ListExtractionFailuresHandler(svc *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extraction failure",
+			})
+			return
+		}
+
+		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if page <= 0 {
+			page = 1
+		}
+		if limit <= 0 || limit > 100 {
+			limit = 20
+		}
+
+		query := svc.DB.Model(&ExtractionFailure{}).Where("user_id = ?", user.ID)
+		if extractorType := c.Query("extractor_type"); extractorType != "" {
+			query = query.Where("extractor_type = ?", extractorType)
+		}
+
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extraction failure",
+			})
+			return
+		}
+
+		var failures []ExtractionFailure
+		if err := query.Order("last_attempt_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&failures).Error; err != nil {
+			response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extraction failure",
+			})
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, ExtractionFailureResponse{
+			Pagination: response.NewPaginationInfo(page, limit, total),
+			Failures:   failures,
+		})
+	}
+}
+
+// POST /structure/failures/:id/requeue
+// 失敗回数と差分取得のチェックポイントをリセットし、次回のバッチで再度解析対象とする
+func RequeueExtractionFailureHandler(svc *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extraction failure",
+			})
+			return
+		}
+
+		var failure ExtractionFailure
+		if err := svc.DB.Where("user_id = ?", user.ID).First(&failure, "id = ?", c.Param("id")).Error; err != nil {
+			code := apierror.Common.DatabaseError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = apierror.Extractor.FailureNotFound
+			}
+			response.SendError(c, code, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extraction failure",
+			})
+			return
+		}
+
+		if err := svc.DB.Model(&failure).Update("attempt_count", 0).Error; err != nil {
+			response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extraction failure",
+			})
+			return
+		}
+
+		// 差分取得ではチェックポイントより前のメールは取得されないため、次回は全件スキャンに戻す
+		if err := svc.requeueSyncState(user, failure.ExtractorType); err != nil {
+			response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extraction failure",
+			})
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, failure)
+	}
+}
@@ -0,0 +1,125 @@
+package extractor
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"shakehandz-api/internal/auth"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 処理済みメールの判定結果
+const (
+	OutcomeExtracted = "extracted" // 構造化して保存済み
+	OutcomeSkipped   = "skipped"   // LLMが対象外と判断し返却しなかった
+	OutcomeFailed    = "failed"    // 解析・検証・保存に失敗（ExtractionFailureに詳細を記録）
+)
+
+// ProcessedMessage は抽出処理が評価したGmailメッセージの台帳
+// extracted / skipped のメールは以降のバッチでLLMへ送信しない
+type ProcessedMessage struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:uq_processed_user_type_message" json:"user_id"`
+	ExtractorType string    `gorm:"type:varchar(20);not null;uniqueIndex:uq_processed_user_type_message" json:"extractor_type"`
+	MessageID     string    `gorm:"type:varchar(255);not null;uniqueIndex:uq_processed_user_type_message" json:"message_id"`
+
+	Outcome     string    `gorm:"type:varchar(20);not null;index" json:"outcome"` // extracted, skipped, failed
+	BatchID     uint      `gorm:"index" json:"batch_id"`
+	ProcessedAt time.Time `gorm:"type:datetime(3);not null" json:"processed_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// recordChunkResults は解析を終えたチャンクの結果を記録する
+// 除外したメールを失敗として記録し、保存できたメールの失敗記録は削除した上で、
+// 送信したメールの判定結果を台帳に記録し、以降のバッチで再送しないようにする
+func (s *Service) recordChunkResults(user auth.User, extractorType string, batchID uint, sentIDs, savedIDs []string, rejected []RejectedRecord) {
+	logRejected(rejected)
+
+	if err := s.recordFailures(user, extractorType, batchID, rejected); err != nil {
+		log.Printf("ERROR: 失敗記録の保存に失敗: %v", err)
+	}
+	if err := s.clearFailures(user, extractorType, savedIDs); err != nil {
+		log.Printf("ERROR: 失敗記録の削除に失敗: %v", err)
+	}
+	if err := s.recordOutcomes(user, extractorType, batchID, sentIDs, savedIDs, rejected); err != nil {
+		log.Printf("ERROR: %v", err)
+	}
+}
+
+// recordOutcomes はLLMへ送信したメールの判定結果を台帳に記録する
+// 保存できたものはextracted、除外したものはfailed、返却されなかったものはskippedとする
+func (s *Service) recordOutcomes(user auth.User, extractorType string, batchID uint, sentIDs []string, savedIDs []string, rejected []RejectedRecord) error {
+	if len(sentIDs) == 0 {
+		return nil
+	}
+
+	outcomes := make(map[string]string, len(sentIDs))
+	for _, id := range sentIDs {
+		outcomes[id] = OutcomeSkipped
+	}
+	for _, r := range rejected {
+		if _, ok := outcomes[r.MessageID]; ok {
+			outcomes[r.MessageID] = OutcomeFailed
+		}
+	}
+	// 同一メールで保存と除外が混在した場合は保存を優先する
+	for _, id := range savedIDs {
+		if _, ok := outcomes[id]; ok {
+			outcomes[id] = OutcomeExtracted
+		}
+	}
+
+	now := time.Now()
+	records := make([]ProcessedMessage, 0, len(outcomes))
+	for _, id := range sentIDs {
+		records = append(records, ProcessedMessage{
+			UserID:        user.ID,
+			ExtractorType: extractorType,
+			MessageID:     id,
+			Outcome:       outcomes[id],
+			BatchID:       batchID,
+			ProcessedAt:   now,
+		})
+	}
+
+	err := s.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"outcome", "batch_id", "processed_at", "updated_at"}),
+	}).Create(&records).Error
+	if err != nil {
+		return fmt.Errorf("処理済みメールの記録に失敗: %w", err)
+	}
+	return nil
+}
+
+// processedMessageIDs は台帳上で処理済み（extracted / skipped）のメッセージIDを取得する
+func (s *Service) processedMessageIDs(user auth.User, extractorType string, messageIDs []string) ([]string, error) {
+	var ids []string
+	err := s.DB.Model(&ProcessedMessage{}).
+		Where("user_id = ? AND extractor_type = ?", user.ID, extractorType).
+		Where("message_id IN ?", messageIDs).
+		Where("outcome IN ?", []string{OutcomeExtracted, OutcomeSkipped}).
+		Pluck("message_id", &ids).Error
+	return ids, err
+}
+
+// BackfillProjectOwners は作成者が未設定の案件（所有者の導入前に抽出したもの）に、
+// 処理台帳から抽出したユーザーを作成者として設定する
+func BackfillProjectOwners(db *gorm.DB) (int64, error) {
+	res := db.Exec(`
+UPDATE projects p
+JOIN (
+	SELECT message_id, MIN(user_id) AS user_id
+	FROM processed_messages
+	WHERE extractor_type = ? AND outcome = ?
+	GROUP BY message_id
+) pm ON pm.message_id = p.email_id
+SET p.created_by_id = pm.user_id, p.updated_by_id = pm.user_id
+WHERE p.created_by_id IS NULL`, TypeProject, OutcomeExtracted)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,144 @@
+package extractor
+
+import (
+	"testing"
+
+	"shakehandz-api/internal/auth"
+
+	"github.com/google/uuid"
+)
+
+// newLedgerTestService は失敗記録・処理台帳のテーブルのみを持つServiceを生成する
+func newLedgerTestService(t *testing.T) *Service {
+	t.Helper()
+	return &Service{DB: newTestDB(t, &ExtractionFailure{}, &ProcessedMessage{})}
+}
+
+func TestRecordFailuresCountsAttempts(t *testing.T) {
+	s := newLedgerTestService(t)
+	user := auth.User{ID: uuid.New()}
+	other := auth.User{ID: uuid.New()}
+
+	batches := []struct {
+		user     auth.User
+		rejected []RejectedRecord
+	}{
+		{user, []RejectedRecord{{MessageID: "m1", Class: RejectDecode}, {MessageID: "m2", Class: RejectValidate}, {MessageID: "", Class: RejectDecode}}},
+		{user, []RejectedRecord{{MessageID: "m1", Class: RejectValidate, Reason: "latest"}}},
+		{user, []RejectedRecord{{MessageID: "m1", Class: RejectDBSave}}},
+		{other, []RejectedRecord{{MessageID: "m1", Class: RejectDecode}}},
+	}
+	for i, b := range batches {
+		if err := s.recordFailures(b.user, TypeHumanResource, uint(i+1), b.rejected); err != nil {
+			t.Fatalf("recordFailures(batch %d) error = %v", i+1, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		user      auth.User
+		messageID string
+		wantCount int
+		wantClass string
+		wantBatch uint
+	}{
+		{name: "失敗のたびに加算し最新の内容で更新する", user: user, messageID: "m1", wantCount: 3, wantClass: RejectDBSave, wantBatch: 3},
+		{name: "1回のみ失敗", user: user, messageID: "m2", wantCount: 1, wantClass: RejectValidate, wantBatch: 1},
+		{name: "ユーザーごとに数える", user: other, messageID: "m1", wantCount: 1, wantClass: RejectDecode, wantBatch: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// datetime(3) はSQLiteで時刻として読み込めないため、検証する項目のみ取得する
+			var f ExtractionFailure
+			err := s.DB.Select("attempt_count, error_class, batch_id").Where("user_id = ? AND extractor_type = ? AND message_id = ?", tt.user.ID, TypeHumanResource, tt.messageID).First(&f).Error
+			if err != nil {
+				t.Fatalf("failure not recorded: %v", err)
+			}
+			if f.AttemptCount != tt.wantCount || f.ErrorClass != tt.wantClass || f.BatchID != tt.wantBatch {
+				t.Errorf("failure = {attempts: %d, class: %s, batch: %d}, want {%d, %s, %d}",
+					f.AttemptCount, f.ErrorClass, f.BatchID, tt.wantCount, tt.wantClass, tt.wantBatch)
+			}
+		})
+	}
+
+	// message_idの無い要素は記録しない
+	var count int64
+	s.DB.Model(&ExtractionFailure{}).Where("message_id = ''").Count(&count)
+	if count != 0 {
+		t.Errorf("failures without message_id = %d, want 0", count)
+	}
+
+	// 上限に達したメールのみ取得対象から除外する
+	exhausted, err := s.exhaustedFailureIDs(user, TypeHumanResource, []string{"m1", "m2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exhausted) != 1 || exhausted[0] != "m1" {
+		t.Errorf("exhaustedFailureIDs() = %v, want [m1]", exhausted)
+	}
+}
+
+func TestRecordOutcomes(t *testing.T) {
+	s := newLedgerTestService(t)
+	user := auth.User{ID: uuid.New()}
+
+	// m1: 保存、m2: 除外、m3: 返却なし、m4: 保存と除外が混在
+	sent := []string{"m1", "m2", "m3", "m4"}
+	saved := []string{"m1", "m4"}
+	rejected := []RejectedRecord{{MessageID: "m2"}, {MessageID: "m4"}, {MessageID: "x9"}}
+	if err := s.recordOutcomes(user, TypeProject, 1, sent, saved, rejected); err != nil {
+		t.Fatalf("recordOutcomes() error = %v", err)
+	}
+	// 再試行で m2 の保存に成功した場合は台帳を更新する
+	if err := s.recordOutcomes(user, TypeProject, 2, []string{"m2"}, []string{"m2"}, nil); err != nil {
+		t.Fatalf("recordOutcomes() error = %v", err)
+	}
+
+	tests := []struct {
+		messageID   string
+		wantOutcome string
+		wantBatch   uint
+	}{
+		{"m1", OutcomeExtracted, 1},
+		{"m2", OutcomeExtracted, 2},
+		{"m3", OutcomeSkipped, 1},
+		{"m4", OutcomeExtracted, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.messageID, func(t *testing.T) {
+			var pm ProcessedMessage
+			err := s.DB.Select("outcome, batch_id").Where("user_id = ? AND extractor_type = ? AND message_id = ?", user.ID, TypeProject, tt.messageID).First(&pm).Error
+			if err != nil {
+				t.Fatalf("outcome not recorded: %v", err)
+			}
+			if pm.Outcome != tt.wantOutcome || pm.BatchID != tt.wantBatch {
+				t.Errorf("outcome = %s (batch %d), want %s (batch %d)", pm.Outcome, pm.BatchID, tt.wantOutcome, tt.wantBatch)
+			}
+		})
+	}
+
+	// 送信していないメールは記録しない
+	var count int64
+	s.DB.Model(&ProcessedMessage{}).Count(&count)
+	if count != int64(len(sent)) {
+		t.Errorf("processed messages = %d, want %d", count, len(sent))
+	}
+}
+
+func TestRecordOutcomesFailedIsRetried(t *testing.T) {
+	s := newLedgerTestService(t)
+	user := auth.User{ID: uuid.New()}
+
+	if err := s.recordOutcomes(user, TypeHumanResource, 1, []string{"m1", "m2"}, nil, []RejectedRecord{{MessageID: "m1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 失敗したメールは処理済みとみなさず、次回のバッチで再送する
+	processed, err := s.processedMessageIDs(user, TypeHumanResource, []string{"m1", "m2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(processed) != 1 || processed[0] != "m2" {
+		t.Errorf("processedMessageIDs() = %v, want [m2]", processed)
+	}
+}
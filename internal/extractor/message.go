@@ -2,83 +2,208 @@ package extractor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"shakehandz-api/internal/auth"
 	"shakehandz-api/internal/humanresource"
+	"shakehandz-api/internal/project"
+	cache_extractor "shakehandz-api/internal/shared/cache/extractor"
 	msg "shakehandz-api/internal/shared/message"
+	gmsg "shakehandz-api/internal/shared/message/gmail"
 
 	"google.golang.org/api/gmail/v1"
 )
 
-func (s *Service) fetchUnprocessedMessages(ctx context.Context, user auth.User, gmail_svc *gmail.Service, target int) ([]*msg.Message, error) {
-	// 解析結果を保持
-	var candidates []*msg.Message
+// 差分取得時に検索範囲を広げる余裕（Gmailの受信日時と記録日時のずれを吸収する）
+const historySearchMargin = 24 * time.Hour
+
+// fetchUnprocessedMessages は未処理メッセージを最大target件取得する
+// チェックポイントがある場合はHistory APIで差分のみを取得し、無い場合や有効期限切れの場合は全件スキャンする
+func (s *Service) fetchUnprocessedMessages(ctx context.Context, user auth.User, gmail_svc *gmail.Service, setting ExtractorSetting) ([]*msg.Message, error) {
+	query := setting.GmailQuery()
+
+	state, err := s.loadSyncState(user, setting.ExtractorType)
+	if err != nil {
+		return nil, fmt.Errorf("同期状態の取得失敗: %w", err)
+	}
+
+	// 検索条件が変更された場合、以前の条件での取り込み状況は使えないため全件スキャンからやり直す
+	if state.HistoryID != 0 && state.Query != query {
+		fmt.Println("検索条件が変更されたため全件スキャンに切り替えます")
+		if err := s.resetSyncState(state); err != nil {
+			return nil, fmt.Errorf("同期状態のリセット失敗: %w", err)
+		}
+	}
+
+	if state.HistoryID != 0 {
+		msgs, err := s.fetchIncrementalMessages(ctx, user, gmail_svc, setting, query, state)
+		if err == nil {
+			return msgs, nil
+		}
+		if !errors.Is(err, gmsg.ErrHistoryExpired) {
+			return nil, err
+		}
+
+		fmt.Println("historyIdの有効期限が切れているため全件スキャンに切り替えます")
+		if err := s.resetSyncState(state); err != nil {
+			return nil, fmt.Errorf("同期状態のリセット失敗: %w", err)
+		}
+	}
+
+	return s.fetchByFullScan(ctx, user, gmail_svc, setting, query, state)
+}
+
+// fetchIncrementalMessages はチェックポイント以降に追加されたメッセージのうち、検索クエリに一致する未処理のものを取得する
+func (s *Service) fetchIncrementalMessages(ctx context.Context, user auth.User, gmail_svc *gmail.Service, setting ExtractorSetting, query string, state *GmailSyncState) ([]*msg.Message, error) {
+	addedIDs, latestHistoryID, err := s.Fetcher.FetchAddedMsgIdsSince(ctx, gmail_svc, state.HistoryID)
+	if err != nil {
+		if errors.Is(err, gmsg.ErrHistoryExpired) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Gmail History API 呼び出し失敗: %w", err)
+	}
+
+	checkpointAt := time.Now()
+	fmt.Printf("差分取得: historyId %d 以降の追加メッセージ数: %d\n", state.HistoryID, len(addedIDs))
+
+	// 新着がなければチェックポイントのみ進める
+	if len(addedIDs) == 0 {
+		return nil, s.saveSyncState(state, query, latestHistoryID, checkpointAt)
+	}
+
+	added := make(map[string]bool, len(addedIDs))
+	for _, id := range addedIDs {
+		added[id] = true
+	}
+
+	// 新着のうち検索クエリに一致するものを、前回チェックポイント以降に絞って検索する
+	since := checkpointAt
+	if state.SyncedAt != nil {
+		since = *state.SyncedAt
+	}
+	incrementalQuery := fmt.Sprintf("%s after:%d", query, since.Add(-historySearchMargin).Unix())
+
+	candidates, drained, err := s.collectUnprocessedIDs(ctx, user, gmail_svc, setting, incrementalQuery, func(id string) bool {
+		return added[id]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := s.fetchDetails(ctx, gmail_svc, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	// 新着に未処理メッセージが残っていない場合のみチェックポイントを進める
+	// 残っている場合は次回も同じhistoryIdから取得し、処理済み台帳で重複を除外する
+	// （解析に失敗したメールを取りこぼさないよう、処理後の次回バッチで進める）
+	if drained && len(candidates) == 0 {
+		if err := s.saveSyncState(state, query, latestHistoryID, checkpointAt); err != nil {
+			return nil, fmt.Errorf("同期状態の保存失敗: %w", err)
+		}
+	}
+
+	return msgs, nil
+}
+
+// fetchByFullScan は検索クエリに一致するメッセージをページングで走査し、未処理のものを取得する
+// 未処理メッセージが無くなった時点でチェックポイントを記録し、以降は差分取得に切り替える
+func (s *Service) fetchByFullScan(ctx context.Context, user auth.User, gmail_svc *gmail.Service, setting ExtractorSetting, query string, state *GmailSyncState) ([]*msg.Message, error) {
+	// 走査中に届いたメールを取りこぼさないよう、走査前のhistoryIdを控えておく
+	startHistoryID, err := s.Fetcher.FetchProfileHistoryID(ctx, gmail_svc)
+	if err != nil {
+		return nil, fmt.Errorf("Gmail プロフィール取得失敗: %w", err)
+	}
+	checkpointAt := time.Now()
+
+	candidates, drained, err := s.collectUnprocessedIDs(ctx, user, gmail_svc, setting, query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := s.fetchDetails(ctx, gmail_svc, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	// 未処理メッセージが無くなった時点で差分取得に切り替える
+	if drained && len(candidates) == 0 {
+		fmt.Println("未処理メッセージを取り切ったため、次回から差分取得に切り替えます")
+		if err := s.saveSyncState(state, query, startHistoryID, checkpointAt); err != nil {
+			return nil, fmt.Errorf("同期状態の保存失敗: %w", err)
+		}
+	}
+
+	return msgs, nil
+}
+
+// collectUnprocessedIDs は検索結果をページングで走査し、未処理のメッセージIDを最大 setting.BatchSize 件返す
+// filterを指定した場合はfilterがtrueを返すIDのみを対象とする
+// 戻り値のdrainedは、走査範囲内の未処理メッセージをすべて返せた場合にtrueとなる
+func (s *Service) collectUnprocessedIDs(ctx context.Context, user auth.User, gmail_svc *gmail.Service, setting ExtractorSetting, query string, filter func(id string) bool) ([]*gmail.Message, bool, error) {
+	extractorType, target, maxPages := setting.ExtractorType, setting.BatchSize, setting.MaxPages
+
+	// 解析対象を保持
+	var candidates []*gmail.Message
 	seenIDs := make(map[string]bool)
 	pageToken := ""
 	pageCount := 0
 
-	// 指定件数に達するまでページングでメッセージを取得
-	for len(candidates) < target && pageCount < MaxPages {
+	// 指定件数に達するまでページングでメッセージIDを取得
+	for pageCount < maxPages {
 		pageCount++
-		fmt.Printf("ページ %d/%d を処理中...\n", pageCount, MaxPages)
+		fmt.Printf("ページ %d/%d を処理中...\n", pageCount, maxPages)
 
-		// ページング対応でメッセージを取得
-		msgs, nextPageToken, err := s.Fetcher.FetchMsgWithPaging(ctx, gmail_svc, "has:attachment", PageSize, pageToken)
+		// ページング対応でメッセージIDのみを取得（詳細は未処理のものだけ取得する）
+		list, nextPageToken, err := s.Fetcher.FetchMsgIdsWithPaging(ctx, gmail_svc, query, int64(setting.PageSize), pageToken)
 		if err != nil {
-			return nil, fmt.Errorf("Gmail API 呼び出し失敗: %w", err)
-		}
-
-		if len(msgs) == 0 {
-			fmt.Println("これ以上のメッセージはありません")
-			break
+			return nil, false, fmt.Errorf("Gmail API 呼び出し失敗: %w", err)
 		}
+		s.publish(ctx, user.ID, extractorType, cache_extractor.Event{
+			Type:    EventPageFetched,
+			Message: fmt.Sprintf("ページ %d/%d を取得しました", pageCount, maxPages),
+			Count:   len(list),
+		})
 
 		// メッセージIDを抽出して重複除外
 		var messageIDs []string
-		for _, msg := range msgs {
-			if !seenIDs[msg.Id] {
-				messageIDs = append(messageIDs, msg.Id)
-				seenIDs[msg.Id] = true
+		var pageMsgs []*gmail.Message
+		for _, m := range list {
+			if seenIDs[m.Id] || (filter != nil && !filter(m.Id)) {
+				continue
 			}
+			seenIDs[m.Id] = true
+			messageIDs = append(messageIDs, m.Id)
+			pageMsgs = append(pageMsgs, m)
 		}
 
-		if len(messageIDs) == 0 {
-			fmt.Println("新しいメッセージIDがありません")
-			pageToken = nextPageToken
-			if pageToken == "" {
-				break
+		if len(messageIDs) > 0 {
+			// DBで既存チェック（MessageIDを使用）
+			existingIDs, err := s.existingMessageIDs(user, extractorType, messageIDs)
+			if err != nil {
+				return nil, false, fmt.Errorf("DB照会失敗: %w", err)
 			}
-			continue
-		}
-
-		// DBで既存チェック（MessageIDを使用）
-		var existingIDs []string
-		err = s.DB.Model(&humanresource.HumanResource{}).
-			Where("created_by_id = ?", user.ID).
-			Where("message_id IN (?)", messageIDs).
-			Pluck("message_id", &existingIDs).Error
-		if err != nil {
-			return nil, fmt.Errorf("DB照会失敗: %w", err)
-		}
 
-		// 既存IDをマップに変換
-		existingIDMap := make(map[string]bool)
-		for _, id := range existingIDs {
-			existingIDMap[id] = true
-		}
-
-		fmt.Printf("取得メッセージ数: %d, DB既存件数: %d\n", len(messageIDs), len(existingIDs))
+			existingIDMap := make(map[string]bool, len(existingIDs))
+			for _, id := range existingIDs {
+				existingIDMap[id] = true
+			}
 
-		// 未処理メッセージのみを candidates に追加
-		for _, msg := range msgs {
-			if !existingIDMap[msg.Id] && len(candidates) < target {
-				candidates = append(candidates, msg)
+			fmt.Printf("取得メッセージ数: %d, DB既存件数: %d\n", len(messageIDs), len(existingIDs))
 
-				// 取得件数がに達した場合でも次ページを取得した場合処理が進行してしまうので、件数に達した場合ここで処理を終了
+			// 未処理メッセージのみを candidates に追加
+			for _, m := range pageMsgs {
+				if existingIDMap[m.Id] {
+					continue
+				}
+				// 目標件数を超える未処理メッセージが残っている場合は取り切れていない
 				if len(candidates) >= target {
-					break
+					return candidates, false, nil
 				}
+				candidates = append(candidates, m)
 			}
 		}
 
@@ -86,17 +211,55 @@ func (s *Service) fetchUnprocessedMessages(ctx context.Context, user auth.User,
 		pageToken = nextPageToken
 		if pageToken == "" {
 			fmt.Println("全ページを処理完了")
-			break
+			return candidates, true, nil
 		}
 	}
 
-	if pageCount >= MaxPages {
-		fmt.Printf("最大ページ数 %d に達しました\n", MaxPages)
+	fmt.Printf("最大ページ数 %d に達しました\n", maxPages)
+	return candidates, len(candidates) < target, nil
+}
+
+// fetchDetails は未処理メッセージの詳細を取得する
+func (s *Service) fetchDetails(ctx context.Context, gmail_svc *gmail.Service, candidates []*gmail.Message) ([]*msg.Message, error) {
+	if len(candidates) == 0 {
+		return []*msg.Message{}, nil
+	}
+	msgs, err := s.Fetcher.FetchMsgDetails(ctx, gmail_svc, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("Gmail メッセージ詳細の取得失敗: %w", err)
+	}
+	return msgs, nil
+}
+
+// existingMessageIDs は処理済み台帳から再送不要なメッセージIDを取得する
+// 台帳導入前に保存されたレコードと、失敗回数が上限に達したメッセージIDもあわせて返す
+func (s *Service) existingMessageIDs(user auth.User, extractorType string, messageIDs []string) ([]string, error) {
+	processedIDs, err := s.processedMessageIDs(user, extractorType, messageIDs)
+	if err != nil {
+		return nil, err
 	}
 
-	// 目標件数まで切り詰め
-	if len(candidates) > target {
-		candidates = candidates[:target]
+	var savedIDs []string
+	if extractorType == TypeProject {
+		err = s.DB.Model(&project.Project{}).
+			Where("created_by_id = ?", user.ID).
+			Where("email_id IN (?)", messageIDs).
+			Pluck("email_id", &savedIDs).Error
+	} else {
+		err = s.DB.Model(&humanresource.HumanResource{}).
+			Where("created_by_id = ?", user.ID).
+			Where("message_id IN (?)", messageIDs).
+			Pluck("message_id", &savedIDs).Error
 	}
-	return candidates, nil
+	if err != nil {
+		return nil, err
+	}
+
+	exhaustedIDs, err := s.exhaustedFailureIDs(user, extractorType, messageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	existingIDs := append(processedIDs, savedIDs...)
+	return append(existingIDs, exhaustedIDs...), nil
 }
@@ -7,13 +7,21 @@ import (
 )
 
 type ExtractorBatchExecution struct {
-	ID     uint      `gorm:"primaryKey"`
-	UserID uuid.UUID `gorm:"type:char(36);not null;index:idx_user_provider"`
+	ID     uint      `gorm:"primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:char(36);not null;index:idx_user_provider" json:"user_id"`
 
-	ExtractorType string    `gorm:"type:varchar(20);not null;index"`          // human_resource, project
-	TriggerFrom   string    `gorm:"type:varchar(20);not null;default:'auto'"` // front, auto
-	ExecutionDate time.Time `gorm:"type:datetime(3);not null;index;default:CURRENT_TIMESTAMP(3)"`
-	Status        string    `gorm:"type:varchar(20);not null;index"` // pending, in_progress, completed, failed
+	ExtractorType string    `gorm:"type:varchar(20);not null;index" json:"extractor_type"`        // human_resource, project
+	TriggerFrom   string    `gorm:"type:varchar(20);not null;default:'auto'" json:"trigger_from"` // front, auto
+	ExecutionDate time.Time `gorm:"type:datetime(3);not null;index;default:CURRENT_TIMESTAMP(3)" json:"execution_date"`
+	Status        string    `gorm:"type:varchar(20);not null;index" json:"status"` // pending, in_progress, completed, no_data, failed, expired, cancelled
+
+	// 処理結果（バッチ終了時に記録）
+	MessagesFetched int        `gorm:"not null;default:0" json:"messages_fetched"`
+	ChunksSent      int        `gorm:"not null;default:0" json:"chunks_sent"`
+	RecordsSaved    int        `gorm:"not null;default:0" json:"records_saved"`
+	RecordsRejected int        `gorm:"not null;default:0" json:"records_rejected"`
+	ErrorMessage    *string    `gorm:"type:text" json:"error_message"`
+	FinishedAt      *time.Time `gorm:"type:datetime(3)" json:"finished_at"`
 }
 
 const (
@@ -23,6 +31,7 @@ const (
 	StatusNoData     = "no_data"
 	StatusFailed     = "failed"
 	StatusExpired    = "expired"
+	StatusCancelled  = "cancelled"
 )
 
 const (
@@ -0,0 +1,141 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 除外理由の分類
+const (
+	RejectLLMParse  = "llm_parse"
+	RejectDecode    = "decode"
+	RejectValidate  = "validation"
+	RejectUnknownID = "unknown_message_id"
+	RejectDBSave    = "db_save"
+)
+
+// RejectedRecord は保存対象から除外した抽出結果
+type RejectedRecord struct {
+	MessageID string
+	Class     string
+	Reason    string
+	Raw       string
+}
+
+// decodeRecords はLLMのレスポンスを配列の要素ごとにデコード・検証し、有効なものと除外したものに振り分ける。
+// 1件の不正な要素がチャンク全体を失敗させないよう、要素単位で判定する
+func decodeRecords[T any](response string, chunk messageChunk, messageID func(*T) string, validate func(*T) error) ([]T, []RejectedRecord) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal([]byte(response), &elements); err != nil {
+		// 配列として解釈できない場合はチャンク内の全メールを除外
+		rejected := make([]RejectedRecord, 0, len(chunk.IDs))
+		for _, id := range chunk.IDs {
+			rejected = append(rejected, RejectedRecord{
+				MessageID: id,
+				Class:     RejectLLMParse,
+				Reason:    fmt.Sprintf("JSON Unmarshal失敗: %v", err),
+				Raw:       response,
+			})
+		}
+		return nil, rejected
+	}
+
+	valid := make([]T, 0, len(elements))
+	var rejected []RejectedRecord
+	seen := make(map[string]struct{}, len(elements))
+
+	for _, raw := range elements {
+		var rec T
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			rejected = append(rejected, RejectedRecord{
+				MessageID: rawMessageID(raw),
+				Class:     RejectDecode,
+				Reason:    err.Error(),
+				Raw:       string(raw),
+			})
+			continue
+		}
+
+		mid := strings.TrimSpace(messageID(&rec))
+		if !slices.Contains(chunk.IDs, mid) {
+			rejected = append(rejected, RejectedRecord{
+				MessageID: mid,
+				Class:     RejectUnknownID,
+				Reason:    "入力に存在しないmessage_idが返却されました",
+				Raw:       string(raw),
+			})
+			continue
+		}
+
+		if err := validate(&rec); err != nil {
+			rejected = append(rejected, RejectedRecord{
+				MessageID: mid,
+				Class:     RejectValidate,
+				Reason:    err.Error(),
+				Raw:       string(raw),
+			})
+			continue
+		}
+
+		// 念の為、MessageIDの重複を除外
+		if _, ok := seen[mid]; ok {
+			continue
+		}
+		seen[mid] = struct{}{}
+		valid = append(valid, rec)
+	}
+
+	return valid, rejected
+}
+
+// rawMessageID はデコードに失敗した要素からmessage_idのみを取り出す
+func rawMessageID(raw json.RawMessage) string {
+	var probe struct {
+		MessageID string `json:"message_id"`
+	}
+	_ = json.Unmarshal(raw, &probe)
+	return strings.TrimSpace(probe.MessageID)
+}
+
+// createRecords はまとめて保存し、失敗した場合は1件ずつ保存して失敗したものだけを除外する
+func createRecords[T any](db *gorm.DB, records []T, messageID func(*T) string) ([]T, []RejectedRecord) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	if err := db.Create(&records).Error; err == nil {
+		return records, nil
+	} else {
+		log.Printf("一括保存に失敗したため1件ずつ保存します: %v", err)
+	}
+
+	saved := make([]T, 0, len(records))
+	var rejected []RejectedRecord
+	for i := range records {
+		rec := records[i]
+		if err := db.Create(&rec).Error; err != nil {
+			raw, _ := json.Marshal(rec)
+			rejected = append(rejected, RejectedRecord{
+				MessageID: messageID(&rec),
+				Class:     RejectDBSave,
+				Reason:    err.Error(),
+				Raw:       string(raw),
+			})
+			continue
+		}
+		saved = append(saved, rec)
+	}
+	return saved, rejected
+}
+
+// logRejected は除外した抽出結果をログに出力する
+func logRejected(rejected []RejectedRecord) {
+	for _, r := range rejected {
+		log.Printf("抽出結果を除外 (message_id: %s, class: %s): %s", r.MessageID, r.Class, r.Reason)
+	}
+}
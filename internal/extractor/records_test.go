@@ -0,0 +1,190 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"shakehandz-api/internal/shared/llm"
+	"shakehandz-api/internal/shared/llm/fake"
+	msg "shakehandz-api/internal/shared/message"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testRecord は抽出結果の代わりに使うレコード
+type testRecord struct {
+	ID        uint   `gorm:"primaryKey" json:"-"`
+	MessageID string `gorm:"uniqueIndex" json:"message_id"`
+	Name      string `json:"name"`
+}
+
+func testRecordMessageID(r *testRecord) string { return r.MessageID }
+
+func (r *testRecord) validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+// newTestDB はテストごとに独立したインメモリのSQLiteを開く
+func newTestDB(t *testing.T, models ...any) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// testChunk はメッセージIDのみのメールをチャンクにする
+func testChunk(ids ...string) messageChunk {
+	msgs := make([]*msg.Message, 0, len(ids))
+	for _, id := range ids {
+		msgs = append(msgs, &msg.Message{Id: id})
+	}
+	return chunkArray(msgs, len(ids))[0]
+}
+
+// generate はLLMの代わりにfakeのProviderでチャンクを解析する
+func generate(t *testing.T, p llm.Provider, chunk messageChunk) string {
+	t.Helper()
+	resp, err := p.GenerateJSON(context.Background(), llm.Request{Input: chunk.JSON})
+	if err != nil {
+		t.Fatalf("GenerateJSON() error = %v", err)
+	}
+	return resp
+}
+
+func TestDecodeRecords(t *testing.T) {
+	tests := []struct {
+		name         string
+		response     string
+		wantValid    []string
+		wantRejected map[string]string // message_id -> 除外理由の分類
+	}{
+		{
+			name:      "すべて有効",
+			response:  `[{"message_id":"m1","name":"A"},{"message_id":"m2","name":"B"}]`,
+			wantValid: []string{"m1", "m2"},
+		},
+		{
+			name:      "不正な要素のみ除外する",
+			response:  `[{"message_id":"m1","name":"A"},{"message_id":"m2","name":1},{"message_id":"m3","name":""},{"message_id":"x9","name":"C"}]`,
+			wantValid: []string{"m1"},
+			wantRejected: map[string]string{
+				"m2": RejectDecode,
+				"m3": RejectValidate,
+				"x9": RejectUnknownID,
+			},
+		},
+		{
+			name:      "重複したmessage_idは最初の要素のみ",
+			response:  `[{"message_id":" m1 ","name":"A"},{"message_id":"m1","name":"B"}]`,
+			wantValid: []string{" m1 "},
+		},
+		{
+			name:     "配列として解釈できない場合はチャンク全体を除外",
+			response: `{"message_id":"m1"}`,
+			wantRejected: map[string]string{
+				"m1": RejectLLMParse,
+				"m2": RejectLLMParse,
+				"m3": RejectLLMParse,
+			},
+		},
+		{
+			name:      "返却されなかったメールは除外しない",
+			response:  `[]`,
+			wantValid: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunk := testChunk("m1", "m2", "m3")
+			p := fake.NewProvider("[]").When(`"id":"m1"`, tt.response)
+
+			valid, rejected := decodeRecords(generate(t, p, chunk), chunk, testRecordMessageID, (*testRecord).validate)
+
+			if len(valid) != len(tt.wantValid) {
+				t.Fatalf("valid = %+v, want %v", valid, tt.wantValid)
+			}
+			for i, r := range valid {
+				if r.MessageID != tt.wantValid[i] {
+					t.Errorf("valid[%d].MessageID = %q, want %q", i, r.MessageID, tt.wantValid[i])
+				}
+			}
+
+			if len(rejected) != len(tt.wantRejected) {
+				t.Fatalf("rejected = %+v, want %v", rejected, tt.wantRejected)
+			}
+			for _, r := range rejected {
+				if want, ok := tt.wantRejected[r.MessageID]; !ok || r.Class != want {
+					t.Errorf("rejected %q class = %q, want %q", r.MessageID, r.Class, want)
+				}
+				if r.Raw == "" {
+					t.Errorf("rejected %q has no raw output", r.MessageID)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateRecords(t *testing.T) {
+	t.Run("まとめて保存", func(t *testing.T) {
+		db := newTestDB(t, &testRecord{})
+		records := []testRecord{{MessageID: "m1", Name: "A"}, {MessageID: "m2", Name: "B"}}
+
+		saved, rejected := createRecords(db, records, testRecordMessageID)
+		if len(saved) != 2 || len(rejected) != 0 {
+			t.Fatalf("saved = %+v, rejected = %+v", saved, rejected)
+		}
+		for _, r := range saved {
+			if r.ID == 0 {
+				t.Errorf("saved %q has no id", r.MessageID)
+			}
+		}
+	})
+
+	t.Run("一括保存に失敗した場合は1件ずつ保存する", func(t *testing.T) {
+		db := newTestDB(t, &testRecord{})
+		if err := db.Create(&testRecord{MessageID: "m2", Name: "existing"}).Error; err != nil {
+			t.Fatal(err)
+		}
+		records := []testRecord{{MessageID: "m1", Name: "A"}, {MessageID: "m2", Name: "B"}, {MessageID: "m3", Name: "C"}}
+
+		saved, rejected := createRecords(db, records, testRecordMessageID)
+
+		if len(saved) != 2 || saved[0].MessageID != "m1" || saved[1].MessageID != "m3" {
+			t.Errorf("saved = %+v, want m1, m3", saved)
+		}
+		if len(rejected) != 1 || rejected[0].MessageID != "m2" || rejected[0].Class != RejectDBSave {
+			t.Errorf("rejected = %+v, want m2 (%s)", rejected, RejectDBSave)
+		}
+
+		var count int64
+		db.Model(&testRecord{}).Count(&count)
+		if count != 3 {
+			t.Errorf("stored records = %d, want 3", count)
+		}
+	})
+
+	t.Run("空の場合は何もしない", func(t *testing.T) {
+		saved, rejected := createRecords[testRecord](nil, nil, testRecordMessageID)
+		if saved != nil || rejected != nil {
+			t.Errorf("saved = %+v, rejected = %+v", saved, rejected)
+		}
+	})
+}
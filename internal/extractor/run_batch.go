@@ -2,139 +2,336 @@ package extractor
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"shakehandz-api/internal/auth"
-
+	cache_extractor "shakehandz-api/internal/shared/cache/extractor"
+	"shakehandz-api/internal/shared/jobqueue"
+	"shakehandz-api/internal/shared/llm"
+	"shakehandz-api/internal/shared/retry"
 	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/api/gmail/v1"
+	"gorm.io/gorm"
 )
 
-func RunHumanResourceExtractionBatch(s *Service, user auth.User, maxExecutionDuration time.Duration, newBatchExecutionFromFront *ExtractorBatchExecution) error {
-	// バッチを起動
-	go func() {
-		bgCtx := context.Background()
-		data, exists := GetClientData(user.ID)
+// ExtractFunc はバッチ1回分の抽出処理。処理対象があった場合はtrueを返す
+type ExtractFunc func(ctx context.Context, user auth.User, client llm.Provider, gmail_svc *gmail.Service, s *Service, currentBatch ExtractorBatchExecution, setting ExtractorSetting) (bool, error)
+
+// ExtractionJobPayload は抽出ジョブ1件の内容。1ジョブ = バッチレコード1件
+type ExtractionJobPayload struct {
+	UserID        uuid.UUID `json:"user_id"`
+	ExtractorType string    `json:"extractor_type"`
+	BatchID       uint      `json:"batch_id"`
+	Trigger       string    `json:"trigger"`
+}
+
+// extractFuncs は抽出種別ごとの処理
+var extractFuncs = map[string]ExtractFunc{
+	TypeHumanResource: Extract,
+	TypeProject:       ExtractProjects,
+}
+
+// extractionJobKey はユーザー・抽出種別ごとに実行中ジョブを1つに保つためのキー
+func extractionJobKey(userID uuid.UUID, extractorType string) string {
+	return fmt.Sprintf("%s:%s", userID, extractorType)
+}
+
+// enqueueBatch はバッチレコードを作成し、runAtに実行されるジョブとして登録する
+// 同じユーザー・種別のジョブが既にある場合はバッチレコードを削除して jobqueue.ErrDuplicateKey を返す
+// supersedes には実行中のジョブから次回のジョブを登録する場合に、そのジョブのIDを指定する
+func (s *Service) enqueueBatch(ctx context.Context, userID uuid.UUID, extractorType, trigger string, runAt time.Time, supersedes uint) (*ExtractorBatchExecution, error) {
+	batch := ExtractorBatchExecution{
+		UserID:        userID,
+		ExtractorType: extractorType,
+		TriggerFrom:   trigger,
+		Status:        StatusPending,
+		ExecutionDate: runAt,
+	}
+	if err := s.DB.WithContext(ctx).Create(&batch).Error; err != nil {
+		return nil, fmt.Errorf("バッチレコード作成エラー: %w", err)
+	}
 
-		if !exists {
-			fmt.Println("クライアントデータが見つかりません")
-			return
+	s.progress(ctx, batch, EventBatchQueued, "実行待ち", 0, func(js *cache_extractor.JobStatus) {
+		js.Status = StatusPending
+		js.Message = "実行待ち"
+	})
+
+	if err := s.enqueueJob(ctx, batch, supersedes); err != nil {
+		if errors.Is(err, jobqueue.ErrDuplicateKey) {
+			// 同時に登録された他のリクエストのジョブが処理するため、このバッチは破棄する
+			s.DB.Delete(&batch)
+			return nil, err
 		}
+		s.setBatchStatus(ctx, batch, StatusFailed, "ジョブの登録に失敗しました", err)
+		return nil, err
+	}
 
-		var retryCount int = 0
-
-		for {
-			// 前回の実行結果を取得
-			var currentBatch ExtractorBatchExecution
-
-			// バッチのステータスを確認
-			if newBatchExecutionFromFront != nil {
-				// 新しいバッチレコードが渡された場合は、画面側からのリクエストと判断
-				// 新しいバッチレコードを現在のバッチとして設定
-				currentBatch = *newBatchExecutionFromFront
-				fmt.Printf("画面からのリクエスト。新しいバッチレコードが渡されました。バッチID: %d\n", currentBatch.ID)
-			} else {
-				// 新しいバッチレコードが渡されなかった場合は、バッチ内での実行と判断
-				// DBから最新のバッチレコードを取得し、前回のバッチステータス確認後に新規でバッチレコードを作成
-				// （バッチ内での実行の場合は、前回のバッチステータス確認後に新規でバッチレコードを作成）
-				// これにより、バッチ内での連続実行が可能になる
-				// ただし、バッチ内での連続実行は、前回のバッチが失敗または有効期限切れの場合はバッチを終了する
-				res := s.DB.Where("user_id = ? AND trigger_from = ?", user.ID, TriggerFront).Order("execution_date desc").First(&currentBatch)
-
-				if res.Error != nil {
-					fmt.Printf("バッチレコード取得エラー: %v\n", res.Error)
-					return
-				}
-
-				// クライアントが有効期限切れ、あるいは失敗ステータスの場合は終了
-				isFailed := currentBatch.Status == StatusFailed
-				isExpired := time.Since(currentBatch.ExecutionDate) > maxExecutionDuration
-				isOnProgress := currentBatch.Status == StatusInProgress
-
-				// 進行中ステータスが長時間続いている場合は失敗扱いとする
-				if isOnProgress {
-					s.DB.Model(&currentBatch).Where("id = ?", currentBatch.ID).Update("status", StatusFailed)
-					fmt.Println("前回のバッチの進行中になんらかの問題が発生しました。失敗扱いとみなします。")
-				}
-
-				// 有効期限切れの場合はバッチを終了
-				if isExpired {
-					// バッチレコードを有効期限切れステータスに更新
-					s.DB.Model(&currentBatch).Where("id = ?", currentBatch.ID).Update("status", StatusExpired)
-					fmt.Printf("有効期限切れによりバッチ処理が終了しました。最終実行日時: %s\n", currentBatch.ExecutionDate)
-					retryCount = 0
-					// バッチを完全終了。画面側からのリクエストを待つのみ
-					break
-				}
-
-				// 失敗ステータスの場合はバッチを終了し、リトライ回数を確認して必要に応じてリトライ
-				if isFailed {
-					s.DB.Model(&currentBatch).Where("id = ?", currentBatch.ID).Update("status", StatusFailed)
-
-					// 失敗ステータスの場合はリトライ回数を確認して、最大リトライ回数に達していなければリトライ
-					if retryCount < MaxRetryCount {
-						fmt.Printf("前回のバッチが失敗しています。リトライを試みます。現在のリトライ回数: %d\n", retryCount+1)
-						retryCount++
-					}
-
-					// 最大リトライ回数に達した場合の処理
-					if retryCount >= MaxRetryCount {
-						// バッチレコードを失敗ステータスに更新
-						fmt.Printf("バッチ処理が%d回失敗しました。最終ステータス: %s\n", MaxRetryCount, currentBatch.Status)
-						retryCount = 0
-						// バッチを完全終了。画面側からのリクエストを待つのみ
-						break
-					}
-
-				}
-
-				// 新規のバッチレコードを作成
-				currentBatch = ExtractorBatchExecution{
-					UserID:        user.ID,
-					ExtractorType: TypeHumanResource,
-					TriggerFrom:   TriggerAuto,
-					Status:        StatusInProgress,
-					ExecutionDate: time.Now(),
-				}
-
-				r := s.DB.Create(&currentBatch)
-
-				if r.Error != nil {
-					fmt.Printf("バッチレコード作成エラー: %v\n", r.Error)
-					return
-				}
-			}
+	return &batch, nil
+}
 
-			// 処理終了後はバッチループとなるため、newBatchExecutionはnilに戻す
-			newBatchExecutionFromFront = nil
+// enqueueJob はバッチレコードを処理するジョブを登録する
+func (s *Service) enqueueJob(ctx context.Context, batch ExtractorBatchExecution, supersedes uint) error {
+	payload, err := json.Marshal(ExtractionJobPayload{
+		UserID:        batch.UserID,
+		ExtractorType: batch.ExtractorType,
+		BatchID:       batch.ID,
+		Trigger:       batch.TriggerFrom,
+	})
+	if err != nil {
+		return err
+	}
 
-			success, err := Extract(bgCtx, user, data.gemini_cli, data.gmail_svc, s, currentBatch)
+	job := jobqueue.Job{
+		Queue:       ExtractionQueue,
+		Key:         extractionJobKey(batch.UserID, batch.ExtractorType),
+		Payload:     payload,
+		RunAt:       batch.ExecutionDate,
+		MaxAttempts: MaxRetryCount,
+		Supersedes:  supersedes,
+	}
+	if err := s.Queue.Enqueue(ctx, &job); err != nil {
+		return fmt.Errorf("ジョブ登録エラー: %w", err)
+	}
+	return nil
+}
 
-			if err != nil {
-				fmt.Printf("Extract処理エラー: %v\n", err)
-				s.DB.Model(&currentBatch).Where("id = ?", currentBatch.ID).Update("status", StatusFailed)
-				break
-			}
+// RecoverPendingBatches は待機中のまま対応するジョブが無いバッチレコードを再登録する
+// ジョブ登録前にプロセスが停止した場合などに、ワーカー起動時に呼び出して取りこぼしを防ぐ
+func (s *Service) RecoverPendingBatches(ctx context.Context) error {
+	var batches []ExtractorBatchExecution
+	if err := s.DB.WithContext(ctx).
+		Where("status = ? AND execution_date > ?", StatusPending, time.Now().Add(-MessageTTL)).
+		Order("execution_date").
+		Find(&batches).Error; err != nil {
+		return err
+	}
 
-			if success {
-				err := s.DB.Model(&currentBatch).Where("id = ?", currentBatch.ID).Update("status", StatusCompleted)
-				retryCount = 0
-				if err.Error != nil {
-					fmt.Printf("バッチレコード更新エラー: %v\n", err.Error)
-				}
-				fmt.Println("次の処理を開始します")
-			} else {
-				// 処理対象がない場合は終了
-				err := s.DB.Model(&currentBatch).Where("id = ?", currentBatch.ID).Update("status", StatusNoData)
-				retryCount = 0
-
-				if err.Error != nil {
-					fmt.Printf("バッチレコード更新エラー: %v\n", err.Error)
-				}
-
-				fmt.Println("処理対象がないため終了")
-				time.Sleep(MaxExecutionDuration)
+	for _, batch := range batches {
+		active, err := s.Queue.HasActive(ctx, ExtractionQueue, extractionJobKey(batch.UserID, batch.ExtractorType))
+		if err != nil {
+			return err
+		}
+		if active {
+			continue
+		}
+		if err := s.enqueueJob(ctx, batch, 0); err != nil {
+			if errors.Is(err, jobqueue.ErrDuplicateKey) {
+				continue
 			}
+			return err
+		}
+		fmt.Printf("待機中のバッチを再登録しました。バッチID: %d\n", batch.ID)
+	}
+	return nil
+}
+
+// NewWorker は抽出ジョブを処理するワーカーを生成する
+func (s *Service) NewWorker(concurrency int) *jobqueue.Worker {
+	return &jobqueue.Worker{
+		Queue:        s.Queue,
+		QueueName:    ExtractionQueue,
+		Handler:      s.HandleExtractionJob,
+		Concurrency:  concurrency,
+		Visibility:   JobVisibilityTimeout,
+		PollInterval: JobPollInterval,
+	}
+}
+
+// HandleExtractionJob はジョブ1件分の抽出を行い、必要に応じて次回のジョブを登録する
+//   - 処理対象があった場合: 直ちに次のジョブを登録
+//   - 処理対象がなかった場合: 設定のポーリング間隔の後に次のジョブを登録
+//   - 画面からの最終リクエストから設定の有効期間を超えた場合: 登録せず終了
+//   - バッチがキャンセルされた場合: 処理を中断し、登録せず終了
+func (s *Service) HandleExtractionJob(ctx context.Context, job *jobqueue.Job) error {
+	var payload ExtractionJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return jobqueue.Permanent(fmt.Errorf("ジョブ内容の解析に失敗しました: %w", err))
+	}
+
+	extract, ok := extractFuncs[payload.ExtractorType]
+	if !ok {
+		return jobqueue.Permanent(fmt.Errorf("不明な抽出種別です: %s", payload.ExtractorType))
+	}
+
+	var user auth.User
+	if err := s.DB.WithContext(ctx).First(&user, "id = ?", payload.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobqueue.Permanent(fmt.Errorf("ユーザーが見つかりません: %s", payload.UserID))
+		}
+		return err
+	}
+
+	var currentBatch ExtractorBatchExecution
+	if err := s.DB.WithContext(ctx).First(&currentBatch, payload.BatchID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobqueue.Permanent(fmt.Errorf("バッチレコードが見つかりません: %d", payload.BatchID))
+		}
+		return err
+	}
+	if currentBatch.Status == StatusCancelled {
+		fmt.Printf("キャンセル済みのバッチのため処理しません。バッチID: %d\n", currentBatch.ID)
+		return nil
+	}
+
+	// キャンセルされた場合はctxを通じてGmail/LLMの呼び出しを中断する
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.watchCancellation(ctx, currentBatch.ID, cancel)
+
+	token, err := auth.FindGoogleTokenByUserID(s.DB.WithContext(ctx), user.ID)
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		err := fmt.Errorf("refresh_tokenが見つかりません: %s", user.ID)
+		s.setBatchStatus(ctx, currentBatch, StatusFailed, "認証情報が見つかりません", err)
+		return jobqueue.Permanent(err)
+	}
+	if token.IsRevoked() {
+		err := fmt.Errorf("refresh_tokenが失効しています: %s", user.ID)
+		s.setBatchStatus(ctx, currentBatch, StatusFailed, "Googleの認証が失効しています。再ログインしてください", err)
+		return jobqueue.Permanent(err)
+	}
+
+	setting, err := s.loadSetting(ctx, user.ID, currentBatch.ExtractorType)
+	if err != nil {
+		return err
+	}
+
+	client, gmail_svc, err := newExtractorClients(ctx, setting.Model, token.RefreshToken)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		if s.handleRevokedToken(ctx, currentBatch, err) {
+			return jobqueue.Permanent(err)
+		}
+		s.setBatchStatus(ctx, currentBatch, StatusFailed, "クライアントの作成に失敗しました", err)
+		return jobError(err)
+	}
+	// 一時的なエラーは呼び出し単位でリトライし、ユーザーごとに呼び出し頻度を制限する
+	client = llm.WithRetry(client, retry.DefaultPolicy, s.LLMLimiter.For(user.ID.String()))
+
+	s.setBatchStatus(ctx, currentBatch, StatusInProgress, "抽出中", nil)
+	fmt.Printf("バッチ処理開始。バッチID: %d 種別: %s 試行: %d\n", currentBatch.ID, currentBatch.ExtractorType, job.Attempts)
+
+	success, err := extract(ctx, user, client, gmail_svc, s, currentBatch, setting)
+	if s.isBatchCancelled(currentBatch.ID) {
+		fmt.Printf("バッチがキャンセルされました。バッチID: %d\n", currentBatch.ID)
+		return nil
+	}
+	if err != nil {
+		fmt.Printf("Extract処理エラー: %v\n", err)
+		// ワーカーの停止による中断はジョブとして再実行されるため、バッチを失敗にしない
+		if ctx.Err() != nil {
+			return err
+		}
+		if s.handleRevokedToken(ctx, currentBatch, err) {
+			return jobqueue.Permanent(err)
+		}
+		s.setBatchStatus(ctx, currentBatch, StatusFailed, "抽出に失敗しました", err)
+		return jobError(err)
+	}
+
+	next := time.Now()
+	status, message := StatusCompleted, "抽出が完了しました"
+	if !success {
+		status, message = StatusNoData, "処理対象のメールがありません"
+		next = next.Add(setting.PollInterval())
+	}
+	// 抽出の完了後にキャンセルされた場合はキャンセルを優先し、次回のジョブも登録しない
+	if !s.setBatchStatus(ctx, currentBatch, status, message, nil) {
+		fmt.Printf("バッチがキャンセルされました。バッチID: %d\n", currentBatch.ID)
+		return nil
+	}
+	if success {
+		fmt.Println("次の処理を開始します")
+	} else {
+		fmt.Println("処理対象がないため待機します")
+	}
+
+	return s.scheduleNextBatch(ctx, job, currentBatch, setting, next)
+}
+
+// handleRevokedToken はエラーがrefresh_tokenの失効によるものであれば、トークンを失効済みにして
+// このユーザーの抽出（全種別の待機中・実行中バッチ）を停止し、trueを返す
+func (s *Service) handleRevokedToken(ctx context.Context, currentBatch ExtractorBatchExecution, err error) bool {
+	if !auth.HandleTokenError(s.DB, currentBatch.UserID, err) {
+		return false
+	}
+
+	fmt.Printf("refresh_tokenが失効しているため抽出を停止します。ユーザー: %s\n", currentBatch.UserID)
+	s.setBatchStatus(ctx, currentBatch, StatusFailed, "Googleの認証が失効しています。再ログインしてください", err)
+
+	// 失効処理はジョブのキャンセルに左右されないよう、親のキャンセルから切り離す
+	stopCtx := context.WithoutCancel(ctx)
+	for extractorType := range extractFuncs {
+		if _, cancelErr := s.CancelBatches(stopCtx, currentBatch.UserID, extractorType); cancelErr != nil {
+			fmt.Printf("バッチの停止に失敗しました: %v\n", cancelErr)
+		}
+	}
+	return true
+}
+
+// jobError は抽出エラーの分類に応じてジョブの再実行方法を決める
+//   - 認証の失効: 再認証されるまで成功しないため再実行しない
+//   - 割り当て超過: QuotaRetryDelay 後に再実行
+//   - その他: ワーカーのバックオフに従って再実行
+func jobError(err error) error {
+	switch retry.Classify(err) {
+	case retry.ClassAuthRevoked:
+		return jobqueue.Permanent(err)
+	case retry.ClassQuota:
+		return jobqueue.RetryAfter(err, QuotaRetryDelay)
+	}
+	return err
+}
+
+// scheduleNextBatch は画面からの最終リクエストが有効期限内であれば次回のジョブを登録する
+func (s *Service) scheduleNextBatch(ctx context.Context, job *jobqueue.Job, currentBatch ExtractorBatchExecution, setting ExtractorSetting, runAt time.Time) error {
+	userID, extractorType := currentBatch.UserID, currentBatch.ExtractorType
+
+	var frontBatch ExtractorBatchExecution
+	err := s.DB.WithContext(ctx).
+		Where("user_id = ? AND extractor_type = ? AND trigger_from = ?", userID, extractorType, TriggerFront).
+		Order("execution_date desc").
+		First(&frontBatch).Error
+	if err != nil {
+		fmt.Printf("バッチレコード取得エラー: %v\n", err)
+		return nil
+	}
+
+	// 有効期限切れの場合はバッチを終了。画面側からのリクエストを待つのみ
+	if time.Since(frontBatch.ExecutionDate) > setting.SessionTTL() {
+		s.DB.Model(&frontBatch).Update("status", StatusExpired)
+		s.progress(ctx, currentBatch, EventBatchExpired, "有効期限切れによりバッチ処理が終了しました", 0, func(js *cache_extractor.JobStatus) {
+			js.Status = StatusExpired
+			js.Message = "有効期限切れによりバッチ処理が終了しました"
+		})
+		fmt.Printf("有効期限切れによりバッチ処理が終了しました。最終リクエスト日時: %s\n", frontBatch.ExecutionDate)
+		return nil
+	}
+
+	if s.isBatchCancelled(currentBatch.ID) {
+		fmt.Printf("バッチがキャンセルされたため次回のバッチを登録しません。バッチID: %d\n", currentBatch.ID)
+		return nil
+	}
+
+	// 次回ジョブの登録に失敗した場合もこのジョブ自体は成功扱いとする
+	// 実行中のこのジョブから次回のジョブにキーを引き継ぐ
+	if _, err := s.enqueueBatch(ctx, userID, extractorType, TriggerAuto, runAt, job.ID); err != nil {
+		fmt.Printf("次回バッチの登録に失敗しました: %v\n", err)
+		return nil
+	}
+
+	// 確認から登録までの間にキャンセルされた場合は、CancelBatches が見逃した次回のバッチを取り消す
+	if s.isBatchCancelled(currentBatch.ID) {
+		if _, err := s.CancelBatches(ctx, userID, extractorType); err != nil {
+			fmt.Printf("次回バッチの取り消しに失敗しました: %v\n", err)
 		}
-		fmt.Println("バッチ処理が終了しました")
-	}()
+	}
 	return nil
 }
@@ -1,27 +1,56 @@
 package extractor
 
 import (
-	"fmt"
+	"log"
+	"strconv"
+
 	"shakehandz-api/internal/auth"
 	"shakehandz-api/internal/humanresource"
+	"shakehandz-api/internal/project"
+	"shakehandz-api/internal/shared/audit"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-func SaveExtractedHumanResources(hrs []humanresource.HumanResource, user auth.User, s *Service) (bool, error) {
-	if len(hrs) > 0 {
-		for i := range hrs {
-			hrs[i].CreatedByID = &user.ID
-			hrs[i].UpdatedByID = &user.ID
-		}
+// SaveExtractedHumanResources は抽出結果を保存し、保存できたものと除外したものを返す
+func SaveExtractedHumanResources(hrs []humanresource.HumanResource, user auth.User, batchID uint, s *Service) ([]humanresource.HumanResource, []RejectedRecord) {
+	for i := range hrs {
+		hrs[i].CreatedByID = &user.ID
+		hrs[i].UpdatedByID = &user.ID
+	}
 
-		// BeforeCreateでUserIDをセットされるので、ここではセット不要
-		if err := s.DB.Create(&hrs).Error; err != nil {
-			return false, fmt.Errorf("DB保存失敗: %w", err)
-		}
+	saved, rejected := createRecords(s.DB, hrs, humanResourceMessageID)
+	recordCreated(s.DB, audit.ResourceHumanResource, saved, func(hr *humanresource.HumanResource) string {
+		return strconv.FormatUint(uint64(hr.ID), 10)
+	}, audit.Extractor(user.ID, batchID))
+
+	// 他社経由で既に登録済みの同一人物とまとめる
+	if err := humanresource.AssignClusters(s.DB, user.ID, saved); err != nil {
+		log.Printf("同一人物の判定に失敗しました: %v", err)
+	}
+	return saved, rejected
+}
 
-		// 成功した場合はtrueを返す
-		return true, nil
+// SaveExtractedProjects は抽出結果を保存し、保存できたものと除外したものを返す
+func SaveExtractedProjects(projects []project.Project, user auth.User, batchID uint, s *Service) ([]project.Project, []RejectedRecord) {
+	// ProjectのIDは文字列の主キーのため、保存前に採番する
+	for i := range projects {
+		if projects[i].ID == "" {
+			projects[i].ID = uuid.NewString()
+		}
+		projects[i].CreatedByID = &user.ID
+		projects[i].UpdatedByID = &user.ID
 	}
 
-	// 何も保存しなかった場合はfalseを返す
-	return false, nil
+	saved, rejected := createRecords(s.DB, projects, func(p *project.Project) string { return p.EmailID })
+	recordCreated(s.DB, audit.ResourceProject, saved, func(p *project.Project) string { return p.ID }, audit.Extractor(user.ID, batchID))
+	return saved, rejected
+}
+
+// recordCreated は抽出で作成したレコードの値を変更履歴に記録する（失敗しても保存結果には影響させない）
+func recordCreated[T any](db *gorm.DB, resourceType string, records []T, resourceID func(*T) string, actor audit.Actor) {
+	if err := audit.RecordCreates(db, resourceType, records, resourceID, actor); err != nil {
+		log.Printf("変更履歴の記録に失敗しました (%s, %d件): %v", resourceType, len(records), err)
+	}
 }
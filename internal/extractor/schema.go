@@ -0,0 +1,12 @@
+package extractor
+
+import (
+	"shakehandz-api/internal/humanresource"
+	"shakehandz-api/internal/shared/llm"
+)
+
+// LLMに返却させるJSON配列のスキーマ。列挙値はモデル定義から生成する
+var (
+	humanResourceSchema = llm.ArrayOf(llm.SchemaFromStruct(humanresource.HumanResource{}))
+	projectSchema       = llm.ArrayOf(llm.SchemaFromStruct(ExtractedProject{}))
+)
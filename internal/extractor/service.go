@@ -1,30 +1,62 @@
 package extractor
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"shakehandz-api/internal/auth"
-	"shakehandz-api/internal/shared/auth/oauth"
+	"shakehandz-api/internal/shared/attachment"
+	cache_extractor "shakehandz-api/internal/shared/cache/extractor"
+	"shakehandz-api/internal/shared/jobqueue"
 	gmsg "shakehandz-api/internal/shared/message/gmail"
+	"shakehandz-api/internal/shared/ratelimit"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 // 　Gmailメッセージ取得→Gemini解析→（将来）DB保存
 type Service struct {
-	Fetcher gmsg.MessageIF
-	DB      *gorm.DB
-	rdb     *redis.Client
+	Fetcher    gmsg.MessageIF
+	DB         *gorm.DB
+	Attachment attachment.Config
+	Queue      jobqueue.Queue
+	Status     cache_extractor.StatusStore
+	Events     cache_extractor.EventBroker
+	LLMLimiter *ratelimit.KeyedLimiter
+	rdb        *redis.Client
+
+	statusMu sync.Mutex
 }
 
 func NewExtractorService(f gmsg.MessageIF, db *gorm.DB, rdb *redis.Client) *Service {
-	return &Service{Fetcher: f, DB: db, rdb: rdb}
+	return &Service{
+		Fetcher:    f,
+		DB:         db,
+		Attachment: attachment.ConfigFromEnv(),
+		Queue:      jobqueue.NewFromEnv(db, rdb),
+		Status:     cache_extractor.NewStatusStore(rdb),
+		Events:     cache_extractor.NewEventBroker(rdb),
+		LLMLimiter: ratelimit.NewKeyedLimiterFromEnv("GEMINI_RATE", GeminiRatePerMinute, GeminiRateBurst),
+		rdb:        rdb,
+	}
 }
 
+// Run は要員メールの抽出バッチを開始する
 func (s *Service) Run(c *gin.Context) error {
+	return s.run(c, TypeHumanResource)
+}
+
+// RunProject は案件メールの抽出バッチを開始する
+func (s *Service) RunProject(c *gin.Context) error {
+	return s.run(c, TypeProject)
+}
+
+func (s *Service) run(c *gin.Context, extractorType string) error {
 	user, err := auth.GetUser(c)
 	if err != nil {
 		fmt.Println("ユーザー情報の取得に失敗しました")
@@ -32,91 +64,47 @@ func (s *Service) Run(c *gin.Context) error {
 		return err
 	}
 
-	verified, err := oauth.IsUserVerified(c)
+	ctx := c.Request.Context()
+
+	// 同じユーザー・種別のジョブが待機中または実行中であれば新たに登録しない
+	active, err := s.Queue.HasActive(ctx, ExtractionQueue, extractionJobKey(user.ID, extractorType))
 	if err != nil {
-		fmt.Println("ユーザーの認証に失敗しました")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve job status"})
 		return err
 	}
-
-	var batchExecution ExtractorBatchExecution
-
-	result := s.DB.Where("user_id = ? AND extractor_type = ?", user.ID, TypeHumanResource).
-		Order("execution_date desc").
-		First(&batchExecution)
-
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve batch execution record"})
-		return result.Error
-	}
-
-	fmt.Printf("RefreshExtractorToken called for user: %s\n", user.ID)
-
-	// 更新不要の場合は何もしない
-	// クライアント更新済みの旨を返却
-	if time.Since(batchExecution.ExecutionDate) <= MessageTTL && batchExecution.Status != StatusFailed {
-		fmt.Println("クライアントの更新は不要です")
+	if active {
+		s.extendFrontBatch(user.ID, extractorType)
 		c.JSON(http.StatusOK, gin.H{"message": "現在バッチが進行中"})
 		return nil
 	}
 
-	// ・本日の最後の処理が失敗している
-	var isFailed bool = batchExecution.Status == StatusFailed
-	// ・最後の処理から24時間以上経過している
-	var isExpired bool = time.Since(batchExecution.ExecutionDate) > MessageTTL
-
-	var newBatchExecution ExtractorBatchExecution
-	// クライアントを更新して返却
-	if isExpired || isFailed {
-		// ユーザ認証
-		cli, gmail_svc, err := RefreshExtractorTokenForBackground(verified)
-		if err != nil {
-			fmt.Println("クライアントの更新に失敗しました")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh extractor token"})
-			return err
-		}
-
-		UpdateClientData(user.ID, gmail_svc, cli)
-
-		if isExpired {
-			fmt.Println("有効期限切れのためClientを更新します")
-		} else {
-			fmt.Println("失敗ステータスのためClientを更新します")
-
-		}
-
-		// 新しいバッチレコードを現在のバッチとして設定
-		newBatchExecution = ExtractorBatchExecution{
-			UserID:        user.ID,
-			ExtractorType: TypeHumanResource,
-			TriggerFrom:   TriggerFront,
-			Status:        StatusInProgress,
-			ExecutionDate: time.Now(),
-		}
-
-		// 新しいバッチレコードを作成
-		r := s.DB.Create(&newBatchExecution)
-
-		if r.Error != nil {
-			fmt.Printf("バッチレコード作成エラー: %v\n", r.Error)
-			return r.Error
-		}
-
+	// 確認後に他のリクエストが登録した場合も、キューの一意制約により進行中として扱う
+	batch, err := s.enqueueBatch(ctx, user.ID, extractorType, TriggerFront, time.Now(), 0)
+	if errors.Is(err, jobqueue.ErrDuplicateKey) {
+		s.extendFrontBatch(user.ID, extractorType)
+		c.JSON(http.StatusOK, gin.H{"message": "現在バッチが進行中"})
+		return nil
 	}
-
-	// バッチ処理を開始
-	// クライアントを更新した場合のみ、新しいバッチレコードを渡す
-	err = RunHumanResourceExtractionBatch(s, user, MessageTTL, &newBatchExecution)
-
 	if err != nil {
 		fmt.Println("バッチ処理の開始に失敗しました")
-
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start batch processing"})
 		return err
 	}
 
+	fmt.Printf("画面からのリクエスト。バッチを登録しました。バッチID: %d\n", batch.ID)
 	c.JSON(http.StatusOK, gin.H{"message": "Extractor token refreshed"})
 
-	return err
+	return nil
+}
+
+// extendFrontBatch は画面からのリクエスト日時を更新し、進行中のバッチの有効期限を延長する
+func (s *Service) extendFrontBatch(userID uuid.UUID, extractorType string) {
+	var frontBatch ExtractorBatchExecution
+	if err := s.DB.Where("user_id = ? AND extractor_type = ? AND trigger_from = ?", userID, extractorType, TriggerFront).
+		Order("execution_date desc").
+		First(&frontBatch).Error; err == nil {
+		s.DB.Model(&frontBatch).Update("execution_date", time.Now())
+	}
 
+	fmt.Println("バッチは既に進行中です")
 }
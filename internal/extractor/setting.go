@@ -0,0 +1,233 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// 設定値の上限
+const (
+	MaxSettingBatchSize   = 50
+	MaxSettingChunkSize   = 10
+	MaxSettingConcurrency = 10
+	MaxSettingPageSize    = 500
+	MaxSettingMaxPages    = 50
+	MinSettingPollSeconds = 60
+)
+
+// ExtractorSetting はユーザー・抽出種別ごとの抽出設定
+// レコードが無い場合や値が0の項目は環境変数（未設定時は constants.go の値）をデフォルトとする
+type ExtractorSetting struct {
+	ID            uint      `gorm:"primaryKey" json:"-"`
+	UserID        uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:uq_setting_user_type" json:"-"`
+	ExtractorType string    `gorm:"type:varchar(20);not null;uniqueIndex:uq_setting_user_type" json:"extractor_type"`
+
+	// Gmailの検索クエリ
+	Query string `gorm:"type:varchar(500);not null" json:"query"`
+	// 対象とするラベル（label:）と除外するラベル（-label:）
+	IncludeLabels datatypes.JSONSlice[string] `gorm:"type:json" json:"include_labels"`
+	ExcludeLabels datatypes.JSONSlice[string] `gorm:"type:json" json:"exclude_labels"`
+
+	// 1回のバッチで処理する最大メール件数
+	BatchSize int `gorm:"not null;default:0" json:"batch_size"`
+	// LLMに1度に渡すメールの件数
+	ChunkSize int `gorm:"not null;default:0" json:"chunk_size"`
+	// LLM呼び出しの同時実行数
+	Concurrency int `gorm:"not null;default:0" json:"concurrency"`
+	// Gmailの1ページあたりの取得件数と最大ページ数
+	PageSize int `gorm:"not null;default:0" json:"page_size"`
+	MaxPages int `gorm:"not null;default:0" json:"max_pages"`
+	// 利用するモデル（Gemini利用時）
+	Model string `gorm:"type:varchar(100);not null" json:"model"`
+	// 処理対象が無かった場合の次回実行までの間隔（秒）
+	PollIntervalSeconds int `gorm:"not null;default:0" json:"poll_interval_seconds"`
+	// 画面からの最終リクエスト後、自動実行を続ける時間（秒）
+	SessionTTLSeconds int `gorm:"not null;default:0" json:"session_ttl_seconds"`
+
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultExtractorSetting は環境変数から抽出設定のデフォルトを生成する
+//
+//	EXTRACTOR_HR_QUERY, EXTRACTOR_PROJECT_QUERY, EXTRACTOR_BATCH_SIZE, EXTRACTOR_CHUNK_SIZE,
+//	EXTRACTOR_CONCURRENCY, EXTRACTOR_PAGE_SIZE, EXTRACTOR_MAX_PAGES, EXTRACTOR_MODEL,
+//	EXTRACTOR_POLL_INTERVAL_SECONDS, EXTRACTOR_SESSION_TTL_SECONDS
+func DefaultExtractorSetting(userID uuid.UUID, extractorType string) ExtractorSetting {
+	query := envString("EXTRACTOR_HR_QUERY", HumanResourceQuery)
+	if extractorType == TypeProject {
+		query = envString("EXTRACTOR_PROJECT_QUERY", ProjectQuery)
+	}
+
+	return ExtractorSetting{
+		UserID:              userID,
+		ExtractorType:       extractorType,
+		Query:               query,
+		IncludeLabels:       datatypes.JSONSlice[string]{},
+		ExcludeLabels:       datatypes.JSONSlice[string]{},
+		BatchSize:           envPositiveInt("EXTRACTOR_BATCH_SIZE", MaxMessages),
+		ChunkSize:           envPositiveInt("EXTRACTOR_CHUNK_SIZE", GeminiChunkSize),
+		Concurrency:         envPositiveInt("EXTRACTOR_CONCURRENCY", MaxGoroutine),
+		PageSize:            envPositiveInt("EXTRACTOR_PAGE_SIZE", PageSize),
+		MaxPages:            envPositiveInt("EXTRACTOR_MAX_PAGES", MaxPages),
+		Model:               envString("EXTRACTOR_MODEL", GeminiModel),
+		PollIntervalSeconds: envPositiveInt("EXTRACTOR_POLL_INTERVAL_SECONDS", int(MaxExecutionDuration/time.Second)),
+		SessionTTLSeconds:   envPositiveInt("EXTRACTOR_SESSION_TTL_SECONDS", int(MessageTTL/time.Second)),
+	}
+}
+
+// withDefaults は未設定（ゼロ値）の項目をデフォルトで補う
+func (es ExtractorSetting) withDefaults() ExtractorSetting {
+	def := DefaultExtractorSetting(es.UserID, es.ExtractorType)
+	if strings.TrimSpace(es.Query) == "" {
+		es.Query = def.Query
+	}
+	if es.IncludeLabels == nil {
+		es.IncludeLabels = def.IncludeLabels
+	}
+	if es.ExcludeLabels == nil {
+		es.ExcludeLabels = def.ExcludeLabels
+	}
+	if es.BatchSize <= 0 {
+		es.BatchSize = def.BatchSize
+	}
+	if es.ChunkSize <= 0 {
+		es.ChunkSize = def.ChunkSize
+	}
+	if es.Concurrency <= 0 {
+		es.Concurrency = def.Concurrency
+	}
+	if es.PageSize <= 0 {
+		es.PageSize = def.PageSize
+	}
+	if es.MaxPages <= 0 {
+		es.MaxPages = def.MaxPages
+	}
+	if strings.TrimSpace(es.Model) == "" {
+		es.Model = def.Model
+	}
+	if es.PollIntervalSeconds <= 0 {
+		es.PollIntervalSeconds = def.PollIntervalSeconds
+	}
+	if es.SessionTTLSeconds <= 0 {
+		es.SessionTTLSeconds = def.SessionTTLSeconds
+	}
+	return es
+}
+
+// Validate は設定値の範囲を検証する
+func (es *ExtractorSetting) Validate() error {
+	var errs []error
+	if strings.TrimSpace(es.Query) == "" && len(es.IncludeLabels) == 0 {
+		errs = append(errs, errors.New("query: 検索クエリまたは対象ラベルを指定してください"))
+	}
+	if len(es.Query) > 500 {
+		errs = append(errs, errors.New("query: 500文字以内で指定してください"))
+	}
+	for _, label := range append(append([]string{}, es.IncludeLabels...), es.ExcludeLabels...) {
+		if strings.TrimSpace(label) == "" {
+			errs = append(errs, errors.New("labels: 空のラベルは指定できません"))
+			break
+		}
+	}
+	checkRange := func(field string, v, min, max int) {
+		if v < min || v > max {
+			errs = append(errs, fmt.Errorf("%s: %d〜%dの範囲で指定してください", field, min, max))
+		}
+	}
+	checkRange("batch_size", es.BatchSize, 1, MaxSettingBatchSize)
+	checkRange("chunk_size", es.ChunkSize, 1, MaxSettingChunkSize)
+	checkRange("concurrency", es.Concurrency, 1, MaxSettingConcurrency)
+	checkRange("page_size", es.PageSize, 1, MaxSettingPageSize)
+	checkRange("max_pages", es.MaxPages, 1, MaxSettingMaxPages)
+	if es.PollIntervalSeconds < MinSettingPollSeconds {
+		errs = append(errs, fmt.Errorf("poll_interval_seconds: %d以上で指定してください", MinSettingPollSeconds))
+	}
+	if es.SessionTTLSeconds < es.PollIntervalSeconds {
+		errs = append(errs, errors.New("session_ttl_seconds: poll_interval_seconds以上で指定してください"))
+	}
+	if strings.TrimSpace(es.Model) == "" {
+		errs = append(errs, errors.New("model: モデル名を指定してください"))
+	}
+	return errors.Join(errs...)
+}
+
+// GmailQuery は検索クエリにラベルの条件を加えたGmailの検索文字列を返す
+func (es ExtractorSetting) GmailQuery() string {
+	terms := []string{}
+	if q := strings.TrimSpace(es.Query); q != "" {
+		terms = append(terms, q)
+	}
+	for _, label := range es.IncludeLabels {
+		terms = append(terms, "label:"+gmailLabelTerm(label))
+	}
+	for _, label := range es.ExcludeLabels {
+		terms = append(terms, "-label:"+gmailLabelTerm(label))
+	}
+	return strings.Join(terms, " ")
+}
+
+// gmailLabelTerm はラベル名を検索用の表記に変換する（空白や/は-に置き換える）
+func gmailLabelTerm(label string) string {
+	return strings.NewReplacer(" ", "-", "/", "-").Replace(strings.TrimSpace(label))
+}
+
+func (es ExtractorSetting) PollInterval() time.Duration {
+	return time.Duration(es.PollIntervalSeconds) * time.Second
+}
+
+func (es ExtractorSetting) SessionTTL() time.Duration {
+	return time.Duration(es.SessionTTLSeconds) * time.Second
+}
+
+// loadSetting はユーザー・抽出種別の設定を取得する。未登録の場合はデフォルトを返す
+func (s *Service) loadSetting(ctx context.Context, userID uuid.UUID, extractorType string) (ExtractorSetting, error) {
+	var setting ExtractorSetting
+	err := s.DB.WithContext(ctx).Where("user_id = ? AND extractor_type = ?", userID, extractorType).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultExtractorSetting(userID, extractorType), nil
+	}
+	if err != nil {
+		return ExtractorSetting{}, err
+	}
+	return setting.withDefaults(), nil
+}
+
+// saveSetting は設定を検証して保存する
+func (s *Service) saveSetting(ctx context.Context, setting *ExtractorSetting) error {
+	if err := setting.Validate(); err != nil {
+		return err
+	}
+
+	var existing ExtractorSetting
+	err := s.DB.WithContext(ctx).Where("user_id = ? AND extractor_type = ?", setting.UserID, setting.ExtractorType).First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	setting.ID = existing.ID
+	setting.CreatedAt = existing.CreatedAt
+	return s.DB.WithContext(ctx).Save(setting).Error
+}
+
+func envString(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
+
+func envPositiveInt(key string, def int) int {
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key))); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
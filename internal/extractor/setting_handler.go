@@ -0,0 +1,143 @@
+package extractor
+
+import (
+	"net/http"
+
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+)
+
+// UpdateExtractorSettingRequest は設定の更新リクエスト。指定した項目のみ更新する
+type UpdateExtractorSettingRequest struct {
+	Query               *string   `json:"query"`
+	IncludeLabels       *[]string `json:"include_labels"`
+	ExcludeLabels       *[]string `json:"exclude_labels"`
+	BatchSize           *int      `json:"batch_size"`
+	ChunkSize           *int      `json:"chunk_size"`
+	Concurrency         *int      `json:"concurrency"`
+	PageSize            *int      `json:"page_size"`
+	MaxPages            *int      `json:"max_pages"`
+	Model               *string   `json:"model"`
+	PollIntervalSeconds *int      `json:"poll_interval_seconds"`
+	SessionTTLSeconds   *int      `json:"session_ttl_seconds"`
+}
+
+func (r UpdateExtractorSettingRequest) apply(setting *ExtractorSetting) {
+	if r.Query != nil {
+		setting.Query = *r.Query
+	}
+	if r.IncludeLabels != nil {
+		setting.IncludeLabels = datatypes.JSONSlice[string](*r.IncludeLabels)
+	}
+	if r.ExcludeLabels != nil {
+		setting.ExcludeLabels = datatypes.JSONSlice[string](*r.ExcludeLabels)
+	}
+	if r.BatchSize != nil {
+		setting.BatchSize = *r.BatchSize
+	}
+	if r.ChunkSize != nil {
+		setting.ChunkSize = *r.ChunkSize
+	}
+	if r.Concurrency != nil {
+		setting.Concurrency = *r.Concurrency
+	}
+	if r.PageSize != nil {
+		setting.PageSize = *r.PageSize
+	}
+	if r.MaxPages != nil {
+		setting.MaxPages = *r.MaxPages
+	}
+	if r.Model != nil {
+		setting.Model = *r.Model
+	}
+	if r.PollIntervalSeconds != nil {
+		setting.PollIntervalSeconds = *r.PollIntervalSeconds
+	}
+	if r.SessionTTLSeconds != nil {
+		setting.SessionTTLSeconds = *r.SessionTTLSeconds
+	}
+}
+
+// GET /structure/{humanresource|project}/settings
+// 抽出設定を取得する（未登録の場合はデフォルト）
+func GetExtractorSettingHandler(svc *Service, extractorType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor setting",
+			})
+			return
+		}
+
+		setting, err := svc.loadSetting(c.Request.Context(), user.ID, extractorType)
+		if err != nil {
+			response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor setting",
+			})
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, setting)
+	}
+}
+
+// PUT /structure/{humanresource|project}/settings
+// 抽出設定を更新する。次回のバッチから反映される
+func UpdateExtractorSettingHandler(svc *Service, extractorType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor setting",
+			})
+			return
+		}
+
+		var req UpdateExtractorSettingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.SendError(c, apierror.Common.JSONParseFailed, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor setting",
+			})
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		setting, err := svc.loadSetting(ctx, user.ID, extractorType)
+		if err != nil {
+			response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor setting",
+			})
+			return
+		}
+		req.apply(&setting)
+
+		if err := setting.Validate(); err != nil {
+			response.SendError(c, apierror.Common.ValidationFailed, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor setting",
+			})
+			return
+		}
+
+		if err := svc.saveSetting(ctx, &setting); err != nil {
+			response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extractor setting",
+			})
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, setting)
+	}
+}
@@ -5,28 +5,35 @@ import (
 	msg "shakehandz-api/internal/shared/message"
 )
 
+// messageChunk はLLMへ1度に渡すメールのまとまり
+type messageChunk struct {
+	// チャンクに含まれるメッセージID（入力順）
+	IDs []string
+	// LLMへ渡すJSON文字列
+	JSON string
+}
+
 // chunkArray は flatArray を chunkSize ごとに分割し、各チャンクをJSON文字列に変換して返す
-func chunkArray(flatArray []*msg.Message, chunkSize int) []string {
+func chunkArray(flatArray []*msg.Message, chunkSize int) []messageChunk {
 	if chunkSize <= 0 {
-		return []string{}
+		return []messageChunk{}
 	}
-	n := len(flatArray)
-	if chunkSize >= n {
-		if jsonStr, err := json.Marshal(flatArray); err == nil {
-			return []string{string(jsonStr)}
-		}
-		return []string{}
-	}
-	var result []string
-	for i := 0; i < n; i += chunkSize {
+	var result []messageChunk
+	for i := 0; i < len(flatArray); i += chunkSize {
 		end := i + chunkSize
-		if end > n {
-			end = n
+		if end > len(flatArray) {
+			end = len(flatArray)
 		}
 		chunk := flatArray[i:end]
-		if jsonStr, err := json.Marshal(chunk); err == nil {
-			result = append(result, string(jsonStr))
+		jsonStr, err := json.Marshal(chunk)
+		if err != nil {
+			continue
+		}
+		ids := make([]string, 0, len(chunk))
+		for _, m := range chunk {
+			ids = append(ids, m.Id)
 		}
+		result = append(result, messageChunk{IDs: ids, JSON: string(jsonStr)})
 	}
 	return result
 }
@@ -0,0 +1,119 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"shakehandz-api/internal/shared/cache"
+	cache_extractor "shakehandz-api/internal/shared/cache/extractor"
+
+	"github.com/google/uuid"
+)
+
+// updateStatus はバッチの進捗ステータスにupdateを適用して保存する
+// 別のバッチのステータスが保存されている場合は、進捗を0から数え直す。更新後の進捗を返す
+func (s *Service) updateStatus(ctx context.Context, batch ExtractorBatchExecution, update func(js *cache_extractor.JobStatus)) *cache_extractor.JobStatus {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	id := cache_extractor.StatusID(batch.UserID, batch.ExtractorType)
+
+	js, err := s.Status.Get(ctx, id)
+	if err != nil && !errors.Is(err, cache_extractor.ErrStatusNotFound) {
+		log.Printf("ERROR: ステータスの取得に失敗: %v", err)
+	}
+	if js == nil || js.BatchID != batch.ID {
+		js = &cache_extractor.JobStatus{
+			RedisCache:    cache.RedisCache{ID: id},
+			BatchID:       batch.ID,
+			ExtractorType: batch.ExtractorType,
+		}
+	}
+
+	update(js)
+	js.UpdatedAt = time.Now()
+
+	if err := s.Status.Set(ctx, js); err != nil {
+		log.Printf("ERROR: ステータスの保存に失敗: %v", err)
+	}
+
+	snapshot := *js
+	return &snapshot
+}
+
+// setBatchStatus はバッチレコードと進捗ステータスの状態を更新する
+// 終了系のステータスでは、進捗の件数と終了日時をバッチレコードにも記録する
+// キャンセル済みのバッチは上書きせず false を返す
+func (s *Service) setBatchStatus(ctx context.Context, batch ExtractorBatchExecution, status, message string, cause error) bool {
+	snapshot := s.updateStatus(ctx, batch, func(js *cache_extractor.JobStatus) {
+		js.Status = status
+		js.Message = message
+		switch status {
+		case StatusInProgress:
+			js.StartedAt = time.Now()
+			js.MessagesFetched = 0
+			js.ChunksSent = 0
+			js.RecordsSaved = 0
+			js.RecordsRejected = 0
+			js.LastError = ""
+			js.FinishedAt = nil
+		case StatusCompleted, StatusNoData, StatusFailed, StatusExpired, StatusCancelled:
+			now := time.Now()
+			js.FinishedAt = &now
+		}
+		if cause != nil {
+			js.LastError = cause.Error()
+		}
+	})
+
+	updates := map[string]interface{}{"status": status}
+	if snapshot.FinishedAt != nil {
+		updates["messages_fetched"] = snapshot.MessagesFetched
+		updates["chunks_sent"] = snapshot.ChunksSent
+		updates["records_saved"] = snapshot.RecordsSaved
+		updates["records_rejected"] = snapshot.RecordsRejected
+		updates["finished_at"] = snapshot.FinishedAt
+	}
+	if cause != nil {
+		updates["error_message"] = cause.Error()
+	}
+	res := s.DB.Model(&ExtractorBatchExecution{}).
+		Where("id = ? AND status <> ?", batch.ID, StatusCancelled).
+		Updates(updates)
+	if res.Error != nil {
+		log.Printf("ERROR: バッチレコード更新エラー: %v", res.Error)
+	}
+	// 処理中にキャンセルされていた場合は、進捗ステータスもキャンセルに戻して通知しない
+	// （値が変わらない更新も0件になるため、キャンセル済みかを確認する）
+	if status != StatusCancelled && res.Error == nil && res.RowsAffected == 0 && s.isBatchCancelled(batch.ID) {
+		s.updateStatus(ctx, batch, func(js *cache_extractor.JobStatus) {
+			js.Status = StatusCancelled
+			js.Message = "バッチがキャンセルされました"
+		})
+		return false
+	}
+
+	if evType, ok := batchStatusEvents[status]; ok {
+		s.publish(ctx, batch.UserID, batch.ExtractorType, cache_extractor.Event{
+			Type:    evType,
+			BatchID: batch.ID,
+			Message: message,
+			Status:  snapshot,
+		})
+	}
+	return true
+}
+
+// GetStatus はユーザー・抽出種別の最新の進捗ステータスを返す。無い場合は待機状態を返す
+func (s *Service) GetStatus(ctx context.Context, userID uuid.UUID, extractorType string) (*cache_extractor.JobStatus, error) {
+	js, err := s.Status.Get(ctx, cache_extractor.StatusID(userID, extractorType))
+	if errors.Is(err, cache_extractor.ErrStatusNotFound) {
+		js = cache_extractor.CreateNewJobStatus("抽出は実行されていません")
+		js.ID = cache_extractor.StatusID(userID, extractorType)
+		js.ExtractorType = extractorType
+		return js, nil
+	}
+	return js, err
+}
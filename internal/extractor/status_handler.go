@@ -0,0 +1,35 @@
+package extractor
+
+import (
+	"net/http"
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExtractionStatusHandler は抽出バッチの進捗ステータスを返す
+func ExtractionStatusHandler(svc *Service, extractorType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extraction status",
+			})
+			return
+		}
+
+		status, err := svc.GetStatus(c.Request.Context(), user.ID, extractorType)
+		if err != nil {
+			response.SendError(c, apierror.Extractor.Unknown, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "extraction status",
+			})
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, status)
+	}
+}
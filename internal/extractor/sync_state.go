@@ -0,0 +1,59 @@
+package extractor
+
+import (
+	"time"
+
+	"shakehandz-api/internal/auth"
+
+	"github.com/google/uuid"
+)
+
+// GmailSyncState はGmail History APIによる差分取得のチェックポイント
+// HistoryIDが0の場合は未処理メールの取り込みが完了していないため、全件スキャンで取得する
+type GmailSyncState struct {
+	ID            uint      `gorm:"primaryKey"`
+	UserID        uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:uq_sync_user_type"`
+	ExtractorType string    `gorm:"type:varchar(20);not null;uniqueIndex:uq_sync_user_type"`
+
+	HistoryID uint64
+	// チェックポイント記録時の検索クエリ。変更された場合は全件スキャンからやり直す
+	Query string `gorm:"type:varchar(1000)"`
+	// チェックポイントを記録した日時。差分取得時の検索範囲の起点とする
+	SyncedAt *time.Time `gorm:"type:datetime(3)"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (s *Service) loadSyncState(user auth.User, extractorType string) (*GmailSyncState, error) {
+	var state GmailSyncState
+	err := s.DB.Where(GmailSyncState{UserID: user.ID, ExtractorType: extractorType}).FirstOrInit(&state).Error
+	return &state, err
+}
+
+// saveSyncState はチェックポイントを更新する
+func (s *Service) saveSyncState(state *GmailSyncState, query string, historyID uint64, syncedAt time.Time) error {
+	state.Query = query
+	state.HistoryID = historyID
+	state.SyncedAt = &syncedAt
+	return s.DB.Save(state).Error
+}
+
+// resetSyncState は差分取得を無効化し、次回は全件スキャンとする
+func (s *Service) resetSyncState(state *GmailSyncState) error {
+	state.HistoryID = 0
+	state.SyncedAt = nil
+	if state.ID == 0 {
+		return nil
+	}
+	return s.DB.Save(state).Error
+}
+
+// requeueSyncState は失敗したメールを再解析できるよう、ユーザー・抽出種別の差分取得を無効化する
+func (s *Service) requeueSyncState(user auth.User, extractorType string) error {
+	state, err := s.loadSyncState(user, extractorType)
+	if err != nil {
+		return err
+	}
+	return s.resetSyncState(state)
+}
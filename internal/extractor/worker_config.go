@@ -0,0 +1,29 @@
+package extractor
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// WorkerConfig は抽出ワーカーの起動設定
+type WorkerConfig struct {
+	// 同時に処理するジョブ数
+	Concurrency int
+	// APIサーバーのプロセス内でワーカーを起動するか（既定はfalse）。
+	// falseの場合、APIサーバーはジョブの登録のみを行い、cmd/worker が処理する
+	InProcess bool
+}
+
+// WorkerConfigFromEnv は環境変数から設定を読み込む
+//
+//	EXTRACTOR_WORKER_CONCURRENCY, EXTRACTOR_INPROCESS_WORKER
+func WorkerConfigFromEnv() WorkerConfig {
+	cfg := WorkerConfig{
+		Concurrency: envPositiveInt("EXTRACTOR_WORKER_CONCURRENCY", JobWorkerConcurrency),
+	}
+	if v, err := strconv.ParseBool(strings.TrimSpace(os.Getenv("EXTRACTOR_INPROCESS_WORKER"))); err == nil {
+		cfg.InProcess = v
+	}
+	return cfg
+}
@@ -0,0 +1,422 @@
+package humanresource
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"shakehandz-api/internal/auth"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 同一人物判定のしきい値と重み
+const (
+	// DedupThreshold 以上のスコアの要員を同一人物とみなす
+	DedupThreshold = 0.6
+
+	dedupWeightAge     = 0.25
+	dedupWeightStation = 0.25
+	dedupWeightSkills  = 0.3
+	dedupWeightRate    = 0.2
+
+	// 単価の差がこの割合以内であれば近いとみなす
+	dedupRateTolerance = 0.1
+	// 年齢の差がこの値以内であれば同一とみなす（メールごとの記載揺れ）
+	dedupAgeTolerance = 1
+)
+
+var (
+	ErrClusterNotFound = errors.New("candidate cluster not found")
+	ErrClusterMembers  = errors.New("human resources not found or not owned")
+)
+
+// CandidateCluster は複数の営業メールから抽出された同一人物と思われる要員のまとまりです。
+// 所属する要員は HumanResource.ClusterID で紐づき、CanonicalID の要員を代表として扱う。
+type CandidateCluster struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	OwnerID     uuid.UUID `gorm:"type:char(36);not null;index" json:"owner_id"`
+	CanonicalID uint      `gorm:"not null" json:"canonical_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CanonicalOnly はクラスタに所属する要員のうち代表以外を除外するスコープ
+func CanonicalOnly(tx *gorm.DB) *gorm.DB {
+	canonical := tx.Session(&gorm.Session{NewDB: true}).Model(&CandidateCluster{}).Select("canonical_id")
+	return tx.Where("cluster_id IS NULL OR id IN (?)", canonical)
+}
+
+// DedupScore は2人の要員が同一人物である可能性を0〜1で返す
+// イニシャルが一致しない場合、または年齢が明らかに異なる場合は0
+func DedupScore(a, b *HumanResource) float64 {
+	ka, kb := normalizeInitial(a.CandidateInitial), normalizeInitial(b.CandidateInitial)
+	if ka == "" || ka != kb || a.MessageID == b.MessageID {
+		return 0
+	}
+
+	score := 0.0
+	if a.Age != nil && b.Age != nil {
+		diff := int(*a.Age) - int(*b.Age)
+		if diff < -dedupAgeTolerance || diff > dedupAgeTolerance {
+			return 0
+		}
+		score += dedupWeightAge
+	}
+	if sa, sb := normalizeStation(a.NearestStation), normalizeStation(b.NearestStation); sa != "" && sa == sb {
+		score += dedupWeightStation
+	}
+	score += dedupWeightSkills * skillOverlap(a.MainSkills, b.MainSkills)
+	if ratesClose(a.MonthlyRateMin, a.MonthlyRateMax, b.MonthlyRateMin, b.MonthlyRateMax) {
+		score += dedupWeightRate
+	}
+	return score
+}
+
+// lockOwnerClusters はトランザクション内で所有者のユーザー行をロックし、クラスタの割り当て・編集を所有者ごとに直列化する
+// APIサーバーと抽出ワーカーが別プロセスで動いていても、同じ所有者のクラスタを同時に作成・更新しないようにする
+func lockOwnerClusters(tx *gorm.DB, ownerID uuid.UUID) error {
+	var ids []uuid.UUID
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Model(&auth.User{}).Where("id = ?", ownerID).Pluck("id", &ids).Error
+}
+
+// AssignClusters は新たに保存した要員を既存の要員と照合し、同一人物と思われる場合はクラスタに追加する
+func AssignClusters(db *gorm.DB, ownerID uuid.UUID, hrs []HumanResource) error {
+	for i := range hrs {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := lockOwnerClusters(tx, ownerID); err != nil {
+				return err
+			}
+			return assignCluster(tx, ownerID, &hrs[i])
+		})
+		if err != nil {
+			return fmt.Errorf("assign cluster (human_resource_id: %d): %w", hrs[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// assignCluster は lockOwnerClusters でロックしたトランザクション内で呼び出すこと
+func assignCluster(tx *gorm.DB, ownerID uuid.UUID, hr *HumanResource) error {
+	key := normalizeInitial(hr.CandidateInitial)
+	if hr.ClusterPinned || hr.ClusterID != nil || key == "" {
+		return nil
+	}
+
+	// ロックを待つ間に他のプロセスがクラスタに追加・削除した場合はそちらを優先する
+	var current HumanResource
+	if err := tx.Select("id", "cluster_id", "cluster_pinned").Where("id = ?", hr.ID).Take(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if current.ClusterPinned || current.ClusterID != nil {
+		hr.ClusterID, hr.ClusterPinned = current.ClusterID, current.ClusterPinned
+		return nil
+	}
+
+	var candidates []HumanResource
+	err := tx.Where("created_by_id = ? AND id <> ?", ownerID, hr.ID).
+		Where("UPPER(REPLACE(REPLACE(REPLACE(candidate_initial, '.', ''), ' ', ''), '．', '')) = ?", key).
+		Find(&candidates).Error
+	if err != nil {
+		return err
+	}
+
+	var best *HumanResource
+	bestScore := 0.0
+	for i := range candidates {
+		c := &candidates[i]
+		// 手動で分割した要員（クラスタ未所属で固定）には自動で紐づけない
+		if c.ClusterPinned && c.ClusterID == nil {
+			continue
+		}
+		if s := DedupScore(hr, c); s >= DedupThreshold && s > bestScore {
+			best, bestScore = c, s
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	clusterID := best.ClusterID
+	if clusterID == nil {
+		cluster := CandidateCluster{OwnerID: ownerID, CanonicalID: best.ID}
+		if err := tx.Create(&cluster).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(best).UpdateColumn("cluster_id", cluster.ID).Error; err != nil {
+			return err
+		}
+		clusterID = &cluster.ID
+	}
+	if err := tx.Model(hr).UpdateColumn("cluster_id", *clusterID).Error; err != nil {
+		return err
+	}
+	hr.ClusterID = clusterID
+	log.Printf("要員を同一人物としてクラスタに追加しました (cluster: %d, human_resource: %d, 類似: %d, score: %.2f)", *clusterID, hr.ID, best.ID, bestScore)
+	return nil
+}
+
+// MergeClusters は指定した要員（と所属するクラスタの要員）を1つのクラスタにまとめる
+// canonicalID が0の場合は既存クラスタの代表、無ければ最も古い要員を代表にする
+func MergeClusters(db *gorm.DB, ownerID uuid.UUID, hrIDs []uint, canonicalID uint) (*CandidateCluster, error) {
+	var cluster CandidateCluster
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOwnerClusters(tx, ownerID); err != nil {
+			return err
+		}
+
+		hrs, err := findOwnedHumanResources(tx, ownerID, hrIDs)
+		if err != nil {
+			return err
+		}
+
+		// 対象の要員が所属しているクラスタ（IDの小さいものを残す）
+		var clusterIDs []uint
+		for _, hr := range hrs {
+			if hr.ClusterID != nil && !slices.Contains(clusterIDs, *hr.ClusterID) {
+				clusterIDs = append(clusterIDs, *hr.ClusterID)
+			}
+		}
+		slices.Sort(clusterIDs)
+
+		memberIDs := hrIDs
+		if len(clusterIDs) > 0 {
+			if err := tx.Where("owner_id = ?", ownerID).First(&cluster, clusterIDs[0]).Error; err != nil {
+				return err
+			}
+			var existing []uint
+			if err := tx.Model(&HumanResource{}).Where("cluster_id IN ?", clusterIDs).Pluck("id", &existing).Error; err != nil {
+				return err
+			}
+			memberIDs = append(slices.Clone(hrIDs), existing...)
+		} else {
+			cluster = CandidateCluster{OwnerID: ownerID, CanonicalID: slices.Min(hrIDs)}
+			if err := tx.Create(&cluster).Error; err != nil {
+				return err
+			}
+		}
+
+		if canonicalID != 0 {
+			if !slices.Contains(memberIDs, canonicalID) {
+				return fmt.Errorf("%w: canonical_id %d is not a member", ErrClusterMembers, canonicalID)
+			}
+			cluster.CanonicalID = canonicalID
+		}
+
+		// 手動でまとめた要員は以後の自動割り当ての対象外にする
+		err = tx.Model(&HumanResource{}).Where("id IN ?", memberIDs).
+			UpdateColumns(map[string]any{"cluster_id": cluster.ID, "cluster_pinned": true}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Save(&cluster).Error; err != nil {
+			return err
+		}
+		if len(clusterIDs) > 1 {
+			return tx.Where("id IN ?", clusterIDs[1:]).Delete(&CandidateCluster{}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cluster, nil
+}
+
+// SplitCluster は指定した要員をクラスタから外す。外した要員は以後自動でクラスタに追加しない
+// 残りが1人以下になったクラスタは解散し、代表を外した場合は最も古い要員を代表にする
+func SplitCluster(db *gorm.DB, ownerID uuid.UUID, clusterID uint, hrIDs []uint) (*CandidateCluster, error) {
+	var cluster CandidateCluster
+	dissolved := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOwnerClusters(tx, ownerID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("owner_id = ?", ownerID).First(&cluster, clusterID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrClusterNotFound
+			}
+			return err
+		}
+
+		var members []uint
+		if err := tx.Model(&HumanResource{}).Where("cluster_id = ?", cluster.ID).Order("id").Pluck("id", &members).Error; err != nil {
+			return err
+		}
+		for _, id := range hrIDs {
+			if !slices.Contains(members, id) {
+				return fmt.Errorf("%w: %d is not a member of cluster %d", ErrClusterMembers, id, cluster.ID)
+			}
+		}
+
+		err := tx.Model(&HumanResource{}).Where("id IN ?", hrIDs).
+			UpdateColumns(map[string]any{"cluster_id": nil, "cluster_pinned": true}).Error
+		if err != nil {
+			return err
+		}
+
+		remaining := slices.DeleteFunc(members, func(id uint) bool { return slices.Contains(hrIDs, id) })
+		if len(remaining) <= 1 {
+			dissolved = true
+			if err := tx.Model(&HumanResource{}).Where("cluster_id = ?", cluster.ID).UpdateColumn("cluster_id", nil).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&cluster).Error
+		}
+		if !slices.Contains(remaining, cluster.CanonicalID) {
+			cluster.CanonicalID = remaining[0]
+			return tx.Save(&cluster).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dissolved {
+		return nil, nil
+	}
+	return &cluster, nil
+}
+
+// leaveCluster は削除する要員をクラスタから外す（lockOwnerClusters でロックしたトランザクション内で呼び出すこと）
+// 残りが1人以下になったクラスタは解散し、代表を外した場合は最も古い要員を代表にする
+func leaveCluster(tx *gorm.DB, hr *HumanResource) error {
+	if hr.ClusterID == nil {
+		return nil
+	}
+	clusterID := *hr.ClusterID
+	if err := tx.Unscoped().Model(hr).UpdateColumn("cluster_id", nil).Error; err != nil {
+		return err
+	}
+	hr.ClusterID = nil
+
+	var cluster CandidateCluster
+	if err := tx.First(&cluster, clusterID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var remaining []uint
+	if err := tx.Model(&HumanResource{}).Where("cluster_id = ?", cluster.ID).Order("id").Pluck("id", &remaining).Error; err != nil {
+		return err
+	}
+	if len(remaining) <= 1 {
+		if err := tx.Model(&HumanResource{}).Where("cluster_id = ?", cluster.ID).UpdateColumn("cluster_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&cluster).Error
+	}
+	if !slices.Contains(remaining, cluster.CanonicalID) {
+		cluster.CanonicalID = remaining[0]
+		return tx.Save(&cluster).Error
+	}
+	return nil
+}
+
+// findOwnedHumanResources はログインユーザーが登録した要員をIDで取得する（全て存在しない場合はエラー）
+func findOwnedHumanResources(db *gorm.DB, ownerID uuid.UUID, ids []uint) ([]HumanResource, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no ids", ErrClusterMembers)
+	}
+	var hrs []HumanResource
+	if err := db.Where("created_by_id = ? AND id IN ?", ownerID, ids).Find(&hrs).Error; err != nil {
+		return nil, err
+	}
+	if len(hrs) != len(ids) {
+		return nil, fmt.Errorf("%w: found %d of %d", ErrClusterMembers, len(hrs), len(ids))
+	}
+	return hrs, nil
+}
+
+// normalizeInitial はイニシャルの表記揺れ（「T.K」「T K」「t.k」）を吸収する
+// SQLでの照合（assignCluster）と同じ規則にすること
+func normalizeInitial(s *string) string {
+	if s == nil {
+		return ""
+	}
+	r := strings.NewReplacer(".", "", " ", "", "．", "")
+	return strings.ToUpper(r.Replace(*s))
+}
+
+// normalizeStation は最寄駅の表記揺れ（「渋谷駅」「渋谷」）を吸収する
+func normalizeStation(s *string) string {
+	if s == nil {
+		return ""
+	}
+	st := strings.TrimSpace(*s)
+	st = strings.TrimSuffix(st, "駅")
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, st))
+}
+
+// skillOverlap はメインスキルの重なり（Jaccard係数）を返す
+func skillOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(a))
+	for _, s := range a {
+		set[strings.ToLower(strings.TrimSpace(s))] = true
+	}
+	inter, union := 0, len(set)
+	seen := make(map[string]bool, len(b))
+	for _, s := range b {
+		k := strings.ToLower(strings.TrimSpace(s))
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		if set[k] {
+			inter++
+		} else {
+			union++
+		}
+	}
+	return float64(inter) / float64(union)
+}
+
+// ratesClose は月額単価の範囲が重なる、または差が許容範囲内かを返す
+func ratesClose(aMin, aMax, bMin, bMax *uint) bool {
+	loA, hiA, okA := rateRange(aMin, aMax)
+	loB, hiB, okB := rateRange(bMin, bMax)
+	if !okA || !okB {
+		return false
+	}
+	if loA <= hiB && loB <= hiA {
+		return true
+	}
+	gap := max(loA, loB) - min(hiA, hiB)
+	return gap <= dedupRateTolerance*max(hiA, hiB)
+}
+
+// rateRange は最小・最大の片方のみ設定されている場合も範囲として扱う（0は未設定）
+func rateRange(minV, maxV *uint) (lo, hi float64, ok bool) {
+	if minV != nil && *minV > 0 {
+		lo, hi, ok = float64(*minV), float64(*minV), true
+	}
+	if maxV != nil && *maxV > 0 {
+		hi = float64(*maxV)
+		if !ok {
+			lo = hi
+		}
+		ok = true
+	}
+	return lo, hi, ok
+}
@@ -0,0 +1,302 @@
+package humanresource
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ClusterSource はクラスタに紐づく元メールの情報です。
+type ClusterSource struct {
+	HumanResourceID uint    `json:"human_resource_id"`
+	MessageID       string  `json:"message_id"`
+	ProviderCompany *string `json:"provider_company,omitempty"`
+	SalesPerson     *string `json:"sales_person,omitempty"`
+	EmailReceivedAt string  `json:"email_received_at"`
+}
+
+// ClusterResponse は代表の要員と、同一人物とみなした要員・元メールの一覧です。
+type ClusterResponse struct {
+	CandidateCluster
+	Canonical *HumanResource  `json:"canonical"`
+	Members   []HumanResource `json:"members"`
+	Sources   []ClusterSource `json:"sources"`
+}
+
+type ClusterListResponse struct {
+	Pagination PaginationInfo    `json:"pagination"`
+	Clusters   []ClusterResponse `json:"clusters"`
+}
+
+type MergeClustersRequest struct {
+	HumanResourceIDs []uint `json:"human_resource_ids" binding:"required,min=2"`
+	// 代表にする要員（省略時は既存クラスタの代表、無ければ最も古い要員）
+	CanonicalID uint `json:"canonical_id"`
+}
+
+type SplitClusterRequest struct {
+	HumanResourceIDs []uint `json:"human_resource_ids" binding:"required,min=1"`
+}
+
+// GetClusters は同一人物のクラスタ一覧を返す
+func (h *HumanResourcesHandler) GetClusters(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	query := h.DB.Model(&CandidateCluster{}).Where("owner_id = ?", user.ID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+		return
+	}
+
+	var clusters []CandidateCluster
+	if err := query.Order("updated_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&clusters).Error; err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+		return
+	}
+
+	data, err := h.clusterResponses(clusters)
+	if err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+		return
+	}
+
+	response.SendSuccess(c, http.StatusOK, ClusterListResponse{
+		Pagination: response.NewPaginationInfo(page, limit, total),
+		Clusters:   data,
+	})
+}
+
+// GetCluster はクラスタの詳細を返す
+func (h *HumanResourcesHandler) GetCluster(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+		return
+	}
+
+	var cluster CandidateCluster
+	if err := h.DB.Where("owner_id = ?", user.ID).First(&cluster, "id = ?", c.Param("clusterId")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrClusterNotFound
+		}
+		sendClusterError(c, err)
+		return
+	}
+
+	h.sendCluster(c, &cluster)
+}
+
+// MergeClusters は指定した要員を同一人物として1つのクラスタにまとめる
+func (h *HumanResourcesHandler) MergeClusters(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+		return
+	}
+
+	var req MergeClustersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendError(c, apierror.Common.ValidationFailed, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+		return
+	}
+
+	ids := uniqueIDs(req.HumanResourceIDs)
+	if len(ids) < 2 {
+		response.SendError(c, apierror.Common.ValidationFailed, response.ErrorDetail{
+			Detail:   "at least 2 distinct human resources are required",
+			Resource: "candidate cluster",
+			Field:    "human_resource_ids",
+		})
+		return
+	}
+
+	cluster, err := MergeClusters(h.DB, user.ID, ids, req.CanonicalID)
+	if err != nil {
+		sendClusterError(c, err)
+		return
+	}
+
+	h.sendCluster(c, cluster)
+}
+
+// SplitCluster は指定した要員をクラスタから外す
+func (h *HumanResourcesHandler) SplitCluster(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+		return
+	}
+
+	clusterID, err := strconv.ParseUint(c.Param("clusterId"), 10, 64)
+	if err != nil {
+		response.SendError(c, apierror.Common.InvalidRequest, response.ErrorDetail{
+			Detail:   "invalid cluster id",
+			Resource: "candidate cluster",
+			Field:    "clusterId",
+		})
+		return
+	}
+
+	var req SplitClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendError(c, apierror.Common.ValidationFailed, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+		return
+	}
+
+	cluster, err := SplitCluster(h.DB, user.ID, uint(clusterID), uniqueIDs(req.HumanResourceIDs))
+	if err != nil {
+		sendClusterError(c, err)
+		return
+	}
+	// 1人以下になったクラスタは解散する
+	if cluster == nil {
+		response.SendSuccess(c, http.StatusOK, gin.H{"id": clusterID, "dissolved": true})
+		return
+	}
+
+	h.sendCluster(c, cluster)
+}
+
+func (h *HumanResourcesHandler) sendCluster(c *gin.Context, cluster *CandidateCluster) {
+	data, err := h.clusterResponses([]CandidateCluster{*cluster})
+	if err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+		return
+	}
+	response.SendSuccess(c, http.StatusOK, data[0])
+}
+
+// clusterResponses はクラスタに所属する要員をまとめて取得してレスポンスを組み立てる
+func (h *HumanResourcesHandler) clusterResponses(clusters []CandidateCluster) ([]ClusterResponse, error) {
+	ids := make([]uint, 0, len(clusters))
+	for _, cl := range clusters {
+		ids = append(ids, cl.ID)
+	}
+
+	var members []HumanResource
+	if len(ids) > 0 {
+		if err := h.DB.Where("cluster_id IN ?", ids).Order("email_received_at DESC").Find(&members).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	byCluster := make(map[uint][]HumanResource, len(clusters))
+	for _, m := range members {
+		byCluster[*m.ClusterID] = append(byCluster[*m.ClusterID], m)
+	}
+
+	out := make([]ClusterResponse, 0, len(clusters))
+	for _, cl := range clusters {
+		res := ClusterResponse{CandidateCluster: cl, Members: byCluster[cl.ID]}
+		for i, m := range res.Members {
+			if m.ID == cl.CanonicalID {
+				res.Canonical = &res.Members[i]
+			}
+			res.Sources = append(res.Sources, ClusterSource{
+				HumanResourceID: m.ID,
+				MessageID:       m.MessageID,
+				ProviderCompany: m.ProviderCompany,
+				SalesPerson:     m.SalesPerson,
+				EmailReceivedAt: m.EmailReceivedAt,
+			})
+		}
+		// 代表の要員が削除されている場合は最新の要員を代表として返す
+		if res.Canonical == nil && len(res.Members) > 0 {
+			res.Canonical = &res.Members[0]
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+func sendClusterError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrClusterNotFound):
+		response.SendError(c, apierror.HumanResource.ClusterNotFound, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+	case errors.Is(err, ErrClusterMembers):
+		response.SendError(c, apierror.Common.ValidationFailed, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+			Field:    "human_resource_ids",
+		})
+	default:
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "candidate cluster",
+		})
+	}
+}
+
+func uniqueIDs(ids []uint) []uint {
+	out := slices.Clone(ids)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// assignClusters は手動登録した要員をクラスタに割り当てる（失敗しても登録は成功として扱う）
+func (h *HumanResourcesHandler) assignClusters(ownerID uuid.UUID, hr *HumanResource) {
+	hrs := []HumanResource{*hr}
+	if err := AssignClusters(h.DB, ownerID, hrs); err != nil {
+		log.Printf("同一人物の判定に失敗しました (human_resource_id: %d): %v", hr.ID, err)
+		return
+	}
+	hr.ClusterID = hrs[0].ClusterID
+}
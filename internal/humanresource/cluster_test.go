@@ -0,0 +1,131 @@
+package humanresource
+
+import (
+	"math"
+	"testing"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestDedupScore(t *testing.T) {
+	// 基準となる要員（比較相手はこれを元に一部の項目を変更する）
+	base := func() HumanResource {
+		return HumanResource{
+			MessageID:        "msg-a",
+			CandidateInitial: ptr("T.Y"),
+			Age:              ptr(uint8(30)),
+			NearestStation:   ptr("渋谷駅"),
+			MainSkills:       []string{"Go", "AWS"},
+			MonthlyRateMin:   ptr(uint(60)),
+			MonthlyRateMax:   ptr(uint(70)),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(b *HumanResource)
+		want   float64
+	}{
+		{
+			name: "全項目一致（表記揺れを吸収）",
+			modify: func(b *HumanResource) {
+				b.CandidateInitial = ptr("t y")
+				b.NearestStation = ptr("渋谷")
+				b.MainSkills = []string{"go", " AWS"}
+			},
+			want: dedupWeightAge + dedupWeightStation + dedupWeightSkills + dedupWeightRate,
+		},
+		{
+			name:   "イニシャル不一致",
+			modify: func(b *HumanResource) { b.CandidateInitial = ptr("K.S") },
+			want:   0,
+		},
+		{
+			name:   "イニシャル未設定",
+			modify: func(b *HumanResource) { b.CandidateInitial = nil },
+			want:   0,
+		},
+		{
+			name:   "同じメール",
+			modify: func(b *HumanResource) { b.MessageID = "msg-a" },
+			want:   0,
+		},
+		{
+			name:   "年齢の差が許容範囲内",
+			modify: func(b *HumanResource) { b.Age = ptr(uint8(31)) },
+			want:   dedupWeightAge + dedupWeightStation + dedupWeightSkills + dedupWeightRate,
+		},
+		{
+			name:   "年齢が明らかに異なる",
+			modify: func(b *HumanResource) { b.Age = ptr(uint8(35)) },
+			want:   0,
+		},
+		{
+			name:   "年齢未設定は加点しない",
+			modify: func(b *HumanResource) { b.Age = nil },
+			want:   dedupWeightStation + dedupWeightSkills + dedupWeightRate,
+		},
+		{
+			name:   "スキルが半分重なる",
+			modify: func(b *HumanResource) { b.MainSkills = []string{"Go", "GCP", "AWS", "Java"} },
+			want:   dedupWeightAge + dedupWeightStation + dedupWeightSkills*0.5 + dedupWeightRate,
+		},
+		{
+			name:   "単価の差が許容範囲内",
+			modify: func(b *HumanResource) { b.MonthlyRateMin = ptr(uint(75)); b.MonthlyRateMax = ptr(uint(76)) },
+			want:   dedupWeightAge + dedupWeightStation + dedupWeightSkills + dedupWeightRate,
+		},
+		{
+			name:   "単価が離れている",
+			modify: func(b *HumanResource) { b.MonthlyRateMin = ptr(uint(90)); b.MonthlyRateMax = nil },
+			want:   dedupWeightAge + dedupWeightStation + dedupWeightSkills,
+		},
+		{
+			name: "イニシャルと年齢のみ一致",
+			modify: func(b *HumanResource) {
+				b.NearestStation = ptr("新宿")
+				b.MainSkills = nil
+				b.MonthlyRateMin, b.MonthlyRateMax = nil, nil
+			},
+			want: dedupWeightAge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := base(), base()
+			b.MessageID = "msg-b"
+			tt.modify(&b)
+
+			got := DedupScore(&a, &b)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("DedupScore() = %v, want %v", got, tt.want)
+			}
+			// 比較の向きによらず同じスコアになる
+			if rev := DedupScore(&b, &a); math.Abs(rev-got) > 1e-9 {
+				t.Errorf("DedupScore() is not symmetric: %v, %v", got, rev)
+			}
+		})
+	}
+}
+
+func TestRatesClose(t *testing.T) {
+	tests := []struct {
+		name                   string
+		aMin, aMax, bMin, bMax *uint
+		want                   bool
+	}{
+		{name: "範囲が重なる", aMin: ptr(uint(50)), aMax: ptr(uint(60)), bMin: ptr(uint(55)), bMax: ptr(uint(65)), want: true},
+		{name: "片方のみ設定", aMin: ptr(uint(60)), bMax: ptr(uint(62)), want: true},
+		{name: "差が10%以内", aMin: ptr(uint(100)), bMin: ptr(uint(109)), want: true},
+		{name: "差が10%超", aMin: ptr(uint(100)), bMin: ptr(uint(112)), want: false},
+		{name: "0は未設定", aMin: ptr(uint(0)), bMin: ptr(uint(0)), want: false},
+		{name: "未設定", aMin: ptr(uint(60)), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ratesClose(tt.aMin, tt.aMax, tt.bMin, tt.bMax); got != tt.want {
+				t.Errorf("ratesClose() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package humanresource
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/audit"
+	"shakehandz-api/internal/shared/options"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 手動登録した要員のmessage_idの接頭辞（メール由来の要員と区別する）
+const ManualMessageIDPrefix = "manual-"
+
+// editableFields は手動で登録・修正できる項目（JSONキーとカラム名は同一）
+var editableFields = map[string]bool{
+	"email_received_at":      true,
+	"provider_company":       true,
+	"sales_person":           true,
+	"candidate_initial":      true,
+	"age":                    true,
+	"nationality":            true,
+	"roles":                  true,
+	"experience_areas":       true,
+	"main_skills":            true,
+	"sub_skills":             true,
+	"additional_info":        true,
+	"employment_type":        true,
+	"work_style":             true,
+	"is_directly_under":      true,
+	"residence":              true,
+	"nearest_station":        true,
+	"available_start_months": true,
+	"monthly_rate_max":       true,
+	"monthly_rate_min":       true,
+	"hourly_rate_max":        true,
+	"hourly_rate_min":        true,
+}
+
+// CreateHumanResource は要員を手動で登録する
+func (h *HumanResourcesHandler) CreateHumanResource(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		response.SendError(c, apierror.Common.InvalidRequest, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	var hr HumanResource
+	if _, ok := audit.BindEditable(c, body, &hr, audit.ResourceHumanResource, editableFields); !ok {
+		return
+	}
+
+	hr.MessageID = ManualMessageIDPrefix + uuid.NewString()
+	if hr.EmailReceivedAt == "" {
+		hr.EmailReceivedAt = time.Now().Format(time.DateTime)
+	}
+	hr.CreatedByID = &user.ID
+	hr.UpdatedByID = &user.ID
+
+	if err := hr.Validate(); err != nil {
+		response.SendError(c, apierror.Common.ValidationFailed, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&hr).Error; err != nil {
+			return err
+		}
+		return audit.RecordCreate(tx, audit.ResourceHumanResource, resourceID(&hr), audit.Manual(user.ID), hr)
+	})
+	if err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	h.saveSkills(append(slices.Clone(hr.MainSkills), hr.SubSkills...))
+	h.assignClusters(user.ID, &hr)
+
+	response.SendSuccess(c, http.StatusCreated, hr)
+}
+
+// UpdateHumanResource は要員の指定した項目のみを修正する（PATCH）
+func (h *HumanResourcesHandler) UpdateHumanResource(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	hr, ok := h.findOwned(c, h.DB, user.ID)
+	if !ok {
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		response.SendError(c, apierror.Common.InvalidRequest, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	if !h.applyUpdate(c, hr, body, audit.ActionUpdate, user.ID) {
+		return
+	}
+
+	response.SendSuccess(c, http.StatusOK, hr)
+}
+
+// applyUpdate は body の項目を要員に反映して保存し、変更履歴を記録する
+// 失敗した場合はエラーレスポンスを送信して false を返す
+func (h *HumanResourcesHandler) applyUpdate(c *gin.Context, hr *HumanResource, body []byte, action audit.Action, userID uuid.UUID) bool {
+	beforeSkills := append(slices.Clone(hr.MainSkills), hr.SubSkills...)
+
+	hr.UpdatedByID = &userID
+	patch := audit.Patch{
+		ResourceType: audit.ResourceHumanResource,
+		ResourceID:   resourceID(hr),
+		Editable:     editableFields,
+		Validate:     hr.Validate,
+	}
+	if !audit.ApplyPatch(c, h.DB, hr, body, patch, action, audit.Manual(userID)) {
+		return false
+	}
+
+	// 新たに追加されたスキルのみ選択肢に反映する
+	var added []string
+	for _, s := range append(slices.Clone(hr.MainSkills), hr.SubSkills...) {
+		if !slices.Contains(beforeSkills, s) {
+			added = append(added, s)
+		}
+	}
+	h.saveSkills(added)
+
+	return true
+}
+
+// DeleteHumanResource は要員を論理削除する
+func (h *HumanResourcesHandler) DeleteHumanResource(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	hr, ok := h.findOwned(c, h.DB, user.ID)
+	if !ok {
+		return
+	}
+
+	// 代表の要員を削除してもクラスタの他の要員が一覧から消えないよう、削除した要員はクラスタから外す
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockOwnerClusters(tx, user.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(hr).UpdateColumn("updated_by_id", user.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(hr).Error; err != nil {
+			return err
+		}
+		if err := leaveCluster(tx, hr); err != nil {
+			return err
+		}
+		return audit.RecordAction(tx, audit.ResourceHumanResource, resourceID(hr), audit.ActionDelete, audit.Manual(user.ID))
+	})
+	if err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	response.SendSuccess(c, http.StatusOK, gin.H{"id": hr.ID})
+}
+
+// RestoreHumanResource は論理削除した要員を復元する
+func (h *HumanResourcesHandler) RestoreHumanResource(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	hr, ok := h.findOwned(c, h.DB.Unscoped().Where("deleted_at IS NOT NULL"), user.ID)
+	if !ok {
+		return
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(hr).Updates(map[string]any{
+			"deleted_at":    nil,
+			"updated_by_id": user.ID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return audit.RecordAction(tx, audit.ResourceHumanResource, resourceID(hr), audit.ActionRestore, audit.Manual(user.ID))
+	})
+	if err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+	hr.DeletedAt = gorm.DeletedAt{}
+	hr.UpdatedByID = &user.ID
+	h.assignClusters(user.ID, hr)
+
+	response.SendSuccess(c, http.StatusOK, hr)
+}
+
+// findOwned はログインユーザーが登録した要員をパスパラメータのIDで取得する
+// 見つからない場合はエラーレスポンスを送信して ok=false を返す
+func (h *HumanResourcesHandler) findOwned(c *gin.Context, db *gorm.DB, userID uuid.UUID) (*HumanResource, bool) {
+	var hr HumanResource
+	err := db.Where("created_by_id = ?", userID).First(&hr, "id = ?", c.Param("id")).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.SendError(c, apierror.HumanResource.NotFound, response.ErrorDetail{
+				Detail:   fmt.Sprintf("human resource %s not found", c.Param("id")),
+				Resource: "human resource",
+			})
+			return nil, false
+		}
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return nil, false
+	}
+	return &hr, true
+}
+
+// resourceID は変更履歴に記録する要員のID
+func resourceID(hr *HumanResource) string {
+	return strconv.FormatUint(uint64(hr.ID), 10)
+}
+
+// saveSkills はスキルの選択肢を更新する（失敗しても要員の保存は成功として扱う）
+func (h *HumanResourcesHandler) saveSkills(skills []string) {
+	if err := options.SaveSkills(h.DB, skills); err != nil {
+		log.Printf("スキルの保存に失敗しました: %v", err)
+	}
+}
@@ -163,6 +163,11 @@ func (h *HumanResourcesHandler) applyFilters(query *gorm.DB, filter *HumanResour
 		query = query.Where("is_directly_under = ?", *filter.Affiliation)
 	}
 
+	// 同一人物のクラスタは代表の要員のみ
+	if filter.CanonicalOnly {
+		query = query.Scopes(CanonicalOnly)
+	}
+
 	if filter.ReceiveAt != "" {
 		query = query.Where("email_received_at >= ?", filter.ReceiveAt)
 	} else {
@@ -0,0 +1,100 @@
+package humanresource
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/audit"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetHumanResourceHistory は要員の変更履歴を新しい順に返す（論理削除済みの要員も対象）
+func (h *HumanResourcesHandler) GetHumanResourceHistory(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	hr, ok := h.findOwned(c, h.DB.Unscoped(), user.ID)
+	if !ok {
+		return
+	}
+
+	logs, err := audit.History(h.DB, audit.ResourceHumanResource, resourceID(hr))
+	if err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	response.SendSuccess(c, http.StatusOK, logs)
+}
+
+// RevertHumanResourceField は変更履歴の項目を変更前の値に戻す
+func (h *HumanResourcesHandler) RevertHumanResourceField(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	logID, err := strconv.ParseUint(c.Param("logId"), 10, 64)
+	if err != nil {
+		response.SendError(c, apierror.Common.InvalidRequest, response.ErrorDetail{
+			Detail:   "invalid log id",
+			Resource: "human resource",
+			Field:    "logId",
+		})
+		return
+	}
+
+	hr, ok := h.findOwned(c, h.DB, user.ID)
+	if !ok {
+		return
+	}
+
+	entry, err := audit.FindFieldChange(h.DB, audit.ResourceHumanResource, resourceID(hr), uint(logID))
+	if err != nil {
+		if errors.Is(err, audit.ErrNotFound) {
+			response.SendError(c, apierror.Audit.LogNotFound, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "human resource",
+			})
+			return
+		}
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	body, err := entry.RevertBody()
+	if err != nil {
+		response.SendError(c, apierror.Common.Unknown, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+		return
+	}
+
+	if !h.applyUpdate(c, hr, body, audit.ActionRevert, user.ID) {
+		return
+	}
+
+	response.SendSuccess(c, http.StatusOK, hr)
+}
@@ -2,6 +2,7 @@ package humanresource
 
 import (
 	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/response"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +22,10 @@ const (
 	EmploymentOther     EmploymentType = "other"
 )
 
+func (EmploymentType) EnumValues() []string {
+	return []string{string(EmploymentFulltime), string(EmploymentFreelance), string(EmploymentOther)}
+}
+
 // 勤務スタイル
 type WorkStyle string
 
@@ -30,6 +35,10 @@ const (
 	WorkStyleOnSite     WorkStyle = "onSite"      // 常駐可能
 )
 
+func (WorkStyle) EnumValues() []string {
+	return []string{string(WorkStyleFullRemote), string(WorkStyleCombined), string(WorkStyleOnSite)}
+}
+
 // 経験領域
 type ExperienceArea string
 
@@ -42,6 +51,13 @@ const (
 	ExpMaintenance    ExperienceArea = "maintenance"
 )
 
+func (ExperienceArea) EnumValues() []string {
+	return []string{
+		string(ExpDefinition), string(ExpBasicDesign), string(ExpDetailedDesign),
+		string(ExpImplementation), string(ExpTesting), string(ExpMaintenance),
+	}
+}
+
 // 役割
 type Role string
 
@@ -60,6 +76,14 @@ const (
 	RoleLowSkill          Role = "lowSkill"
 )
 
+func (Role) EnumValues() []string {
+	return []string{
+		string(RoleDevelopment), string(RoleInfrastructure), string(RoleMobile), string(RoleTestAndQuality),
+		string(RoleDataAnalytics), string(RoleProjectManagement), string(RoleHelpdesk), string(RoleSecurity),
+		string(RoleDevOpsSRE), string(RoleProductOwner), string(RoleConsulting), string(RoleLowSkill),
+	}
+}
+
 // 国籍
 type Nationality string
 
@@ -69,11 +93,15 @@ const (
 	NatNaturalized Nationality = "naturalized"
 )
 
+func (Nationality) EnumValues() []string {
+	return []string{string(NatJapan), string(NatForeigner), string(NatNaturalized)}
+}
+
 /* ---------- モデル ---------- */
 
 type HumanResource struct {
 	/* 0. 一意キー */
-	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"id" llm:"-"`
 	MessageID string `gorm:"type:varchar(255);uniqueIndex" json:"message_id"`
 
 	/* メールから直接抜ける情報 */
@@ -109,17 +137,21 @@ type HumanResource struct {
 	HourlyRateMax        *uint                    `json:"hourly_rate_max,omitempty"`
 	HourlyRateMin        *uint                    `json:"hourly_rate_min,omitempty"`
 
-	/* メタ情報 */
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	CreatedByID *uuid.UUID     `gorm:"type:char(36)" json:"created_by_id,omitempty"`
-	UpdatedByID *uuid.UUID     `gorm:"type:char(36)" json:"updated_by_id,omitempty"`
+	/* メタ情報（LLMのレスポンススキーマからは除外） */
+	CreatedAt   time.Time      `json:"created_at" llm:"-"`
+	UpdatedAt   time.Time      `json:"updated_at" llm:"-"`
+	CreatedByID *uuid.UUID     `gorm:"type:char(36)" json:"created_by_id,omitempty" llm:"-"`
+	UpdatedByID *uuid.UUID     `gorm:"type:char(36)" json:"updated_by_id,omitempty" llm:"-"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
+	/* 重複排除（同一人物のクラスタ。CandidateCluster 参照） */
+	ClusterID     *uint `gorm:"index" json:"cluster_id,omitempty" llm:"-"`
+	ClusterPinned bool  `gorm:"not null;default:false" json:"cluster_pinned" llm:"-"`
+
 	// Userモデルとのリレーションを定義
 	// これにより、Preloadなどでユーザー情報を一緒に取得できる
-	Creator *auth.User `gorm:"foreignKey:CreatedByID;references:ID" json:"creator,omitempty"`
-	Updater *auth.User `gorm:"foreignKey:UpdatedByID;references:ID" json:"updater,omitempty"`
+	Creator *auth.User `gorm:"foreignKey:CreatedByID;references:ID" json:"creator,omitempty" llm:"-"`
+	Updater *auth.User `gorm:"foreignKey:UpdatedByID;references:ID" json:"updater,omitempty" llm:"-"`
 }
 
 type HumanResourceFilter struct {
@@ -142,6 +174,9 @@ type HumanResourceFilter struct {
 	// スイッチ（真偽値）
 	Affiliation *bool `form:"affiliation" json:"affiliation"`
 
+	// 同一人物のクラスタは代表の要員のみ返す
+	CanonicalOnly bool `form:"canonical_only" json:"canonical_only"`
+
 	// 最もふるい受信日
 	ReceiveAt string `form:"receive_at" json:"receive_at"`
 
@@ -156,9 +191,5 @@ type HumanResourceResponse struct {
 	HumanResourcesData []HumanResource      `json:"humanResourcesData"`
 }
 
-type PaginationInfo struct {
-	Page       int   `json:"page"`
-	Limit      int   `json:"limit"`
-	Total      int64 `json:"total"`
-	TotalPages int64 `json:"total_pages"`
-}
+// PaginationInfo は response.PaginationInfo の別名（既存の参照のために残している）
+type PaginationInfo = response.PaginationInfo
@@ -0,0 +1,101 @@
+package humanresource
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// 年齢の上限（これを超える値は抽出ミスとみなす）
+	MaxAge = 99
+	// 参画可能月の上限（0は即日）
+	MaxStartMonth = 12
+)
+
+// Valid は定義済みの雇用体系かを判定する
+func (e EmploymentType) Valid() bool { return slices.Contains(e.EnumValues(), string(e)) }
+
+// Valid は定義済みの勤務スタイルかを判定する
+func (w WorkStyle) Valid() bool { return slices.Contains(w.EnumValues(), string(w)) }
+
+// Valid は定義済みの経験領域かを判定する
+func (e ExperienceArea) Valid() bool { return slices.Contains(e.EnumValues(), string(e)) }
+
+// Valid は定義済みの役割かを判定する
+func (r Role) Valid() bool { return slices.Contains(r.EnumValues(), string(r)) }
+
+// Valid は定義済みの国籍かを判定する
+func (n Nationality) Valid() bool { return slices.Contains(n.EnumValues(), string(n)) }
+
+// Validate は列挙型・数値範囲・文字数がDBの定義に収まっているかを検証する
+func (hr *HumanResource) Validate() error {
+	if strings.TrimSpace(hr.MessageID) == "" {
+		return errors.New("message_id is required")
+	}
+	if hr.Age != nil && *hr.Age > MaxAge {
+		return fmt.Errorf("age out of range: %d", *hr.Age)
+	}
+	for _, m := range hr.AvailableStartMonths {
+		if m < 0 || m > MaxStartMonth {
+			return fmt.Errorf("available_start_months out of range: %d", m)
+		}
+	}
+	if err := validateRange("monthly_rate", hr.MonthlyRateMin, hr.MonthlyRateMax); err != nil {
+		return err
+	}
+	if err := validateRange("hourly_rate", hr.HourlyRateMin, hr.HourlyRateMax); err != nil {
+		return err
+	}
+
+	// varcharカラムの文字数
+	lengths := []struct {
+		name  string
+		value *string
+		max   int
+	}{
+		{"attachment_type", hr.AttachmentType, 50},
+		{"attachment_filename", hr.AttachmentFilename, 255},
+		{"provider_company", hr.ProviderCompany, 255},
+		{"sales_person", hr.SalesPerson, 255},
+		{"candidate_initial", hr.CandidateInitial, 10},
+		{"residence", hr.Residence, 255},
+		{"nearest_station", hr.NearestStation, 255},
+	}
+	for _, l := range lengths {
+		if l.value != nil && utf8.RuneCountInString(*l.value) > l.max {
+			return fmt.Errorf("%s too long (max: %d)", l.name, l.max)
+		}
+	}
+
+	if hr.Nationality != nil && !hr.Nationality.Valid() {
+		return fmt.Errorf("invalid nationality: %q", *hr.Nationality)
+	}
+	if hr.EmploymentType != nil && !hr.EmploymentType.Valid() {
+		return fmt.Errorf("invalid employment_type: %q", *hr.EmploymentType)
+	}
+	if hr.WorkStyle != nil && !hr.WorkStyle.Valid() {
+		return fmt.Errorf("invalid work_style: %q", *hr.WorkStyle)
+	}
+	for _, r := range hr.Roles {
+		if !r.Valid() {
+			return fmt.Errorf("invalid roles: %q", r)
+		}
+	}
+	for _, e := range hr.ExperienceAreas {
+		if !e.Valid() {
+			return fmt.Errorf("invalid experience_areas: %q", e)
+		}
+	}
+	return nil
+}
+
+// validateRange は最小値が最大値を超えていないかを検証する（0は未設定扱い）
+func validateRange(name string, min, max *uint) error {
+	if min != nil && max != nil && *min > 0 && *max > 0 && *min > *max {
+		return fmt.Errorf("invalid %s range: min %d is greater than max %d", name, *min, *max)
+	}
+	return nil
+}
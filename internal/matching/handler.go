@@ -0,0 +1,99 @@
+package matching
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/humanresource"
+	"shakehandz-api/internal/project"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProjectMatchesResponse struct {
+	Project project.Project  `json:"project"`
+	Matches []CandidateMatch `json:"matches"`
+}
+
+type HumanResourceMatchesResponse struct {
+	HumanResource humanresource.HumanResource `json:"human_resource"`
+	Matches       []ProjectMatch              `json:"matches"`
+}
+
+// ProjectMatchesHandler は案件に適合する要員のランキングを返す
+// GET /projects/:id/matches?limit=20&min_score=0&days=30
+func ProjectMatchesHandler(svc *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "matching",
+			})
+			return
+		}
+
+		p, matches, err := svc.MatchCandidates(user.ID, c.Param("id"), parseOptions(c))
+		if err != nil {
+			sendMatchError(c, err)
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, ProjectMatchesResponse{Project: *p, Matches: matches})
+	}
+}
+
+// HumanResourceMatchesHandler は要員に適合する案件のランキングを返す
+// GET /humanresource/:id/matches?limit=20&min_score=0&days=30
+func HumanResourceMatchesHandler(svc *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "matching",
+			})
+			return
+		}
+
+		hr, matches, err := svc.MatchProjects(user.ID, c.Param("id"), parseOptions(c))
+		if err != nil {
+			sendMatchError(c, err)
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, HumanResourceMatchesResponse{HumanResource: *hr, Matches: matches})
+	}
+}
+
+// parseOptions はクエリパラメータを解析する（不正な値は既定値にする）
+func parseOptions(c *gin.Context) Options {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	days, _ := strconv.Atoi(c.Query("days"))
+	minScore, _ := strconv.ParseFloat(c.Query("min_score"), 64)
+	return Options{Limit: limit, Days: days, MinScore: minScore}
+}
+
+func sendMatchError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrProjectNotFound):
+		response.SendError(c, apierror.Project.NotFound, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+	case errors.Is(err, ErrHumanResourceNotFound):
+		response.SendError(c, apierror.HumanResource.NotFound, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "human resource",
+		})
+	default:
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "matching",
+		})
+	}
+}
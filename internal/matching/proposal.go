@@ -0,0 +1,158 @@
+package matching
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"shakehandz-api/internal/humanresource"
+	"shakehandz-api/internal/project"
+	"shakehandz-api/internal/shared/llm"
+	"shakehandz-api/internal/shared/llm/provider"
+	"shakehandz-api/internal/shared/retry"
+	"shakehandz-api/prompts"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 提案文の生成に使用するモデル（PROPOSAL_LLM_MODEL で上書き可能）
+const DefaultProposalModel = "models/gemini-2.5-flash"
+
+var ErrProposalNotFound = errors.New("match proposal not found")
+
+// MatchProposal は案件と要員の組み合わせに対してLLMが生成した適合理由と提案メールの下書きです。
+// ユーザー・案件・要員ごとに1件保存し、再生成するまで再利用する。
+type MatchProposal struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	OwnerID         uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:uq_proposal_target,priority:1" json:"owner_id"`
+	ProjectID       string    `gorm:"type:varchar(64);not null;uniqueIndex:uq_proposal_target,priority:2" json:"project_id"`
+	HumanResourceID uint      `gorm:"not null;uniqueIndex:uq_proposal_target,priority:3" json:"human_resource_id"`
+	Score           float64   `json:"score"`
+	Explanation     string    `gorm:"type:text" json:"explanation"`
+	Subject         string    `gorm:"type:varchar(255)" json:"subject"`
+	Body            string    `gorm:"type:text" json:"body"`
+	Model           string    `gorm:"type:varchar(64)" json:"model"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// proposalOutput はLLMに返却させるJSONの構造（prompts/match-proposal-instruction.txt の出力スキーマ）
+type proposalOutput struct {
+	Explanation string `json:"explanation"`
+	Subject     string `json:"subject"`
+	Body        string `json:"body"`
+}
+
+// proposalInput はLLMに渡す入力
+type proposalInput struct {
+	Project       project.Project             `json:"project"`
+	HumanResource humanresource.HumanResource `json:"human_resource"`
+	Score         Score                       `json:"score"`
+}
+
+var proposalSchema = llm.SchemaFromStruct(proposalOutput{})
+
+func proposalModel() string {
+	if m := strings.TrimSpace(os.Getenv("PROPOSAL_LLM_MODEL")); m != "" {
+		return m
+	}
+	return DefaultProposalModel
+}
+
+// FindProposal は保存済みの提案文を取得する
+func (s *Service) FindProposal(userID uuid.UUID, projectID, hrID string) (*MatchProposal, error) {
+	var mp MatchProposal
+	err := s.DB.Where("owner_id = ? AND project_id = ? AND human_resource_id = ?", userID, projectID, hrID).First(&mp).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProposalNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mp, nil
+}
+
+// GenerateProposal は案件と要員の適合理由と提案メールの下書きをLLMで生成して保存する
+// 保存済みの場合は regenerate=true の場合のみ再生成する
+func (s *Service) GenerateProposal(ctx context.Context, userID uuid.UUID, encRefresh []byte, projectID, hrID string, regenerate bool) (*MatchProposal, error) {
+	var p project.Project
+	if err := s.DB.Where("created_by_id = ?", userID).First(&p, "id = ?", projectID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+	var hr humanresource.HumanResource
+	if err := s.DB.Where("created_by_id = ?", userID).First(&hr, "id = ?", hrID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHumanResourceNotFound
+		}
+		return nil, err
+	}
+
+	existing, err := s.FindProposal(userID, p.ID, strconv.FormatUint(uint64(hr.ID), 10))
+	if err != nil && !errors.Is(err, ErrProposalNotFound) {
+		return nil, err
+	}
+	if existing != nil && !regenerate {
+		return existing, nil
+	}
+
+	score := Evaluate(&p, &hr)
+	input, err := json.Marshal(proposalInput{Project: p, HumanResource: hr, Score: score})
+	if err != nil {
+		return nil, fmt.Errorf("marshal input: %w", err)
+	}
+
+	model := proposalModel()
+	client, err := provider.New(ctx, model, encRefresh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create llm provider: %w", err)
+	}
+	client = llm.WithRetry(client, retry.DefaultPolicy, s.LLMLimiter.For(userID.String()))
+
+	raw, err := client.GenerateJSON(ctx, llm.Request{
+		SystemPrompt: prompts.MatchProposalInstruction,
+		Input:        string(input),
+		Schema:       proposalSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var out proposalOutput
+	if err := json.Unmarshal([]byte(llm.TrimJSONFence(raw)), &out); err != nil {
+		return nil, fmt.Errorf("decode llm response: %w", err)
+	}
+	if strings.TrimSpace(out.Subject) == "" || strings.TrimSpace(out.Body) == "" {
+		return nil, llm.ErrEmptyResponse
+	}
+
+	mp := MatchProposal{
+		OwnerID:         userID,
+		ProjectID:       p.ID,
+		HumanResourceID: hr.ID,
+		Score:           score.Total,
+		Explanation:     strings.TrimSpace(out.Explanation),
+		Subject:         strings.TrimSpace(out.Subject),
+		Body:            strings.TrimSpace(out.Body),
+		Model:           model,
+	}
+
+	// 同じ組み合わせで同時に生成された場合も一意制約で失敗しないよう、後から保存した内容で上書きする
+	err = s.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner_id"}, {Name: "project_id"}, {Name: "human_resource_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "explanation", "subject", "body", "model", "updated_at"}),
+	}).Create(&mp).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.FindProposal(userID, p.ID, strconv.FormatUint(uint64(hr.ID), 10))
+}
@@ -0,0 +1,83 @@
+package matching
+
+import (
+	"errors"
+	"net/http"
+
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/auth/oauth"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProposalHandler は保存済みの提案文を返す
+// GET /projects/:id/matches/:hrId/proposal
+func GetProposalHandler(svc *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := auth.GetUser(c)
+		if err != nil {
+			response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "match proposal",
+			})
+			return
+		}
+
+		mp, err := svc.FindProposal(user.ID, c.Param("id"), c.Param("hrId"))
+		if err != nil {
+			sendProposalError(c, err)
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, mp)
+	}
+}
+
+// GenerateProposalHandler は適合理由と提案メールの下書きを生成する（保存済みの場合はそれを返す）
+// POST /projects/:id/matches/:hrId/proposal?regenerate=true
+func GenerateProposalHandler(svc *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// エラー時のレスポンスは IsUserVerified で送信済み
+		verified, err := oauth.IsUserVerified(c)
+		if err != nil {
+			return
+		}
+
+		regenerate := c.Query("regenerate") == "true"
+		mp, err := svc.GenerateProposal(c.Request.Context(), verified.User.ID, verified.Token.RefreshToken,
+			c.Param("id"), c.Param("hrId"), regenerate)
+		if err != nil {
+			// refresh_tokenが失効している場合は再ログインを促す
+			if auth.HandleTokenError(svc.DB, verified.User.ID, err) {
+				response.SendError(c, apierror.Auth.TokenExpired, response.ErrorDetail{
+					Detail:   "google refresh token revoked",
+					Resource: "match proposal",
+				})
+				return
+			}
+			sendProposalError(c, err)
+			return
+		}
+
+		response.SendSuccess(c, http.StatusOK, mp)
+	}
+}
+
+func sendProposalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrProposalNotFound):
+		response.SendError(c, apierror.Matching.ProposalNotFound, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "match proposal",
+		})
+	case errors.Is(err, ErrProjectNotFound), errors.Is(err, ErrHumanResourceNotFound):
+		sendMatchError(c, err)
+	default:
+		response.SendError(c, apierror.Matching.ProposalFailed, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "match proposal",
+		})
+	}
+}
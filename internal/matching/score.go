@@ -0,0 +1,356 @@
+// Package matching は案件と要員の適合度を採点し、条件ごとの内訳付きでランキングします。
+package matching
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"shakehandz-api/internal/humanresource"
+	"shakehandz-api/internal/project"
+)
+
+// 採点の観点
+type Criterion string
+
+const (
+	CriterionSkill    Criterion = "skill"
+	CriterionRate     Criterion = "rate"
+	CriterionStart    Criterion = "start_month"
+	CriterionRemote   Criterion = "remote"
+	CriterionLocation Criterion = "location"
+)
+
+// 観点ごとの重み（合計1）
+var weights = map[Criterion]float64{
+	CriterionSkill:    0.4,
+	CriterionRate:     0.2,
+	CriterionStart:    0.15,
+	CriterionRemote:   0.15,
+	CriterionLocation: 0.1,
+}
+
+// 判定に必要な情報が欠けている観点の点数（減点も加点もしない）
+const unknownScore = 0.5
+
+const (
+	// サブスキルのみ一致した場合の点数の割合
+	subSkillCredit = 0.5
+	// 尚可スキル1件あたりの重み（必須スキル1件を1とする）
+	niceToHaveWeight = 0.3
+	// 希望単価が予算をこの割合まで超えている場合は段階的に減点する（超えると0点）
+	rateOverTolerance = 0.2
+	// 参画可能月と開始月がこの月数以上ずれている場合は0点
+	startMonthTolerance = 3
+)
+
+// remote_work_frequency の値（project-instruction.txt の変換マッピング）
+const (
+	RemoteFull     = "フルリモート"
+	RemoteCombined = "リモート併用"
+	RemoteOnSite   = "常駐"
+)
+
+// 案件の勤務形態 × 要員の勤務スタイル の適合度
+var remoteFit = map[string]map[humanresource.WorkStyle]float64{
+	RemoteFull: {
+		humanresource.WorkStyleFullRemote: 1,
+		humanresource.WorkStyleCombined:   1,
+		humanresource.WorkStyleOnSite:     1,
+	},
+	RemoteCombined: {
+		humanresource.WorkStyleFullRemote: 0.3,
+		humanresource.WorkStyleCombined:   1,
+		humanresource.WorkStyleOnSite:     1,
+	},
+	RemoteOnSite: {
+		humanresource.WorkStyleFullRemote: 0,
+		humanresource.WorkStyleCombined:   0.5,
+		humanresource.WorkStyleOnSite:     1,
+	},
+}
+
+// CriterionScore は観点ごとの点数（0〜1）と判定理由です。
+type CriterionScore struct {
+	Criterion Criterion `json:"criterion"`
+	Score     float64   `json:"score"`
+	Weight    float64   `json:"weight"`
+	Known     bool      `json:"known"`
+	Reason    string    `json:"reason"`
+}
+
+// Score は案件と要員の適合度です。Total は観点ごとの点数の加重平均を0〜100にしたもの。
+type Score struct {
+	Total     float64          `json:"total"`
+	Breakdown []CriterionScore `json:"breakdown"`
+}
+
+// Evaluate は案件と要員の適合度を採点する
+func Evaluate(p *project.Project, hr *humanresource.HumanResource) Score {
+	breakdown := []CriterionScore{
+		scoreSkill(p, hr),
+		scoreRate(p, hr),
+		scoreStart(p, hr),
+		scoreRemote(p, hr),
+		scoreLocation(p, hr),
+	}
+
+	total := 0.0
+	for i := range breakdown {
+		breakdown[i].Weight = weights[breakdown[i].Criterion]
+		breakdown[i].Score = round(breakdown[i].Score, 2)
+		total += breakdown[i].Weight * breakdown[i].Score
+	}
+	return Score{Total: round(total*100, 1), Breakdown: breakdown}
+}
+
+func unknown(c Criterion, reason string) CriterionScore {
+	return CriterionScore{Criterion: c, Score: unknownScore, Reason: reason}
+}
+
+// RequiredSkills は案件の必須スキルを一覧にする
+func RequiredSkills(p *project.Project) []string {
+	return trimSkills(p.RequiredSkills)
+}
+
+// NiceToHaveSkills は案件の尚可スキルを一覧にする
+func NiceToHaveSkills(p *project.Project) []string {
+	return trimSkills(p.NiceToHaveSkills)
+}
+
+func trimSkills(skills []string) []string {
+	var out []string
+	for _, s := range skills {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// scoreSkill は案件のスキルのうち要員が持っているものの割合（サブスキルのみの一致は半分、尚可スキルは重みを下げて加味する）
+func scoreSkill(p *project.Project, hr *humanresource.HumanResource) CriterionScore {
+	required, niceToHave := RequiredSkills(p), NiceToHaveSkills(p)
+	if len(required) == 0 && len(niceToHave) == 0 {
+		return unknown(CriterionSkill, "案件のスキルが未設定")
+	}
+	if len(hr.MainSkills) == 0 && len(hr.SubSkills) == 0 {
+		return CriterionScore{Criterion: CriterionSkill, Known: true, Reason: "要員のスキルが未設定"}
+	}
+
+	reqCredit, reqMatched, reqPartial := skillCredit(hr, required)
+	niceCredit, niceMatched, nicePartial := skillCredit(hr, niceToHave)
+
+	total := float64(len(required)) + niceToHaveWeight*float64(len(niceToHave))
+	score := (reqCredit + niceToHaveWeight*niceCredit) / total
+
+	var reasons []string
+	if len(required) > 0 {
+		reasons = append(reasons, skillReason("必須スキル", len(required), reqMatched, reqPartial))
+	}
+	if len(niceToHave) > 0 {
+		reasons = append(reasons, skillReason("尚可スキル", len(niceToHave), niceMatched, nicePartial))
+	}
+	return CriterionScore{Criterion: CriterionSkill, Score: score, Known: true, Reason: strings.Join(reasons, "、")}
+}
+
+// skillCredit は要員のスキルと一致した件数を点数にする（メイン一致は1、サブ一致は subSkillCredit）
+func skillCredit(hr *humanresource.HumanResource, skills []string) (credit float64, matched, partial []string) {
+	for _, skill := range skills {
+		switch {
+		case containsSkill(hr.MainSkills, skill):
+			matched = append(matched, skill)
+			credit += 1
+		case containsSkill(hr.SubSkills, skill):
+			partial = append(partial, skill)
+			credit += subSkillCredit
+		}
+	}
+	return credit, matched, partial
+}
+
+func skillReason(label string, total int, matched, partial []string) string {
+	reason := fmt.Sprintf("%s%d件中 メイン一致%d件・サブ一致%d件", label, total, len(matched), len(partial))
+	if len(matched)+len(partial) > 0 {
+		reason += "（" + strings.Join(append(matched, partial...), "、") + "）"
+	}
+	return reason
+}
+
+// containsSkill は必須スキルの記述（例「Java経験3年以上」）に要員のスキル名が含まれるかを判定する
+func containsSkill(skills []string, required string) bool {
+	req := strings.ToLower(required)
+	return slices.ContainsFunc(skills, func(s string) bool {
+		s = strings.ToLower(strings.TrimSpace(s))
+		return s != "" && (containsWord(req, s) || containsWord(s, req))
+	})
+}
+
+// containsWord は英字の単語の途中での一致（「Java」と「JavaScript」など）を除いて部分一致を判定する
+func containsWord(text, word string) bool {
+	for i := 0; ; {
+		j := strings.Index(text[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		if !isASCIILetter(text, start-1) && !isASCIILetter(text, end) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isASCIILetter(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return 'a' <= c && c <= 'z'
+}
+
+// scoreRate は要員の希望単価（下限）が案件の予算（上限）に収まっているか
+// 案件の単価の単位が時給（円/時）の場合は時給、それ以外は月額（万円/月）で比較する
+func scoreRate(p *project.Project, hr *humanresource.HumanResource) CriterionScore {
+	budget, ok := upper(p.UnitPriceMin, p.UnitPriceMax)
+	if !ok {
+		return unknown(CriterionRate, "案件の単価が未設定")
+	}
+
+	unit := "万円/月"
+	candMin, candMax := hr.MonthlyRateMin, hr.MonthlyRateMax
+	if p.UnitPriceUnit != nil && strings.Contains(*p.UnitPriceUnit, "時") {
+		unit = "円/時"
+		candMin, candMax = hr.HourlyRateMin, hr.HourlyRateMax
+	}
+	desired, ok := lower(candMin, candMax)
+	if !ok {
+		return unknown(CriterionRate, "要員の希望単価が未設定")
+	}
+
+	if desired <= budget {
+		return CriterionScore{Criterion: CriterionRate, Score: 1, Known: true,
+			Reason: fmt.Sprintf("希望%d%sが予算%d%s以内", desired, unit, budget, unit)}
+	}
+	over := float64(desired-budget) / float64(budget)
+	return CriterionScore{Criterion: CriterionRate, Score: math.Max(0, 1-over/rateOverTolerance), Known: true,
+		Reason: fmt.Sprintf("希望%d%sが予算%d%sを%.0f%%超過", desired, unit, budget, unit, over*100)}
+}
+
+// now は開始月のずれを数える基準の現在時刻
+var now = time.Now
+
+// scoreStart は要員の参画可能月（0は即日）と案件の開始月のずれ
+// 参画可能月は年を持たないため、現在の月以降で最も近い年月として数える
+func scoreStart(p *project.Project, hr *humanresource.HumanResource) CriterionScore {
+	if p.ProjectStartMonth == nil {
+		return unknown(CriterionStart, "案件の開始月が未設定")
+	}
+	if len(hr.AvailableStartMonths) == 0 {
+		return unknown(CriterionStart, "要員の参画可能時期が未設定")
+	}
+
+	current := monthIndex(now())
+	start := monthIndex(*p.ProjectStartMonth)
+	label := p.ProjectStartMonth.Format("2006年1月")
+	if start < current {
+		return unknown(CriterionStart, "案件の開始月（"+label+"）を過ぎている")
+	}
+
+	best := math.MaxInt
+	for _, m := range hr.AvailableStartMonths {
+		// 即日参画可能（0）はいつ開始の案件にも参画できる
+		if m == 0 {
+			best = 0
+			break
+		}
+		d := upcomingMonth(current, m) - start
+		if d < 0 {
+			d = -d
+		}
+		best = min(best, d)
+	}
+
+	reason := fmt.Sprintf("開始%s / 参画可能%v（ずれ%dヶ月）", label, []int(hr.AvailableStartMonths), best)
+	return CriterionScore{Criterion: CriterionStart, Score: math.Max(0, 1-float64(best)/startMonthTolerance), Known: true, Reason: reason}
+}
+
+// monthIndex は年月を通算の月数にする
+func monthIndex(t time.Time) int {
+	return t.Year()*12 + int(t.Month()) - 1
+}
+
+// upcomingMonth は暦月 m（1〜12）を、現在の月（通算の月数）以降で最も近い年月の通算の月数にする
+func upcomingMonth(current, m int) int {
+	idx := current - current%12 + m - 1
+	if idx < current {
+		idx += 12
+	}
+	return idx
+}
+
+// scoreRemote は案件の勤務形態と要員の希望する勤務スタイルの適合度
+func scoreRemote(p *project.Project, hr *humanresource.HumanResource) CriterionScore {
+	if p.RemoteWorkFrequency == nil {
+		return unknown(CriterionRemote, "案件の勤務形態が未設定")
+	}
+	fit, ok := remoteFit[strings.TrimSpace(*p.RemoteWorkFrequency)]
+	if !ok {
+		return unknown(CriterionRemote, "案件の勤務形態を判定できません: "+*p.RemoteWorkFrequency)
+	}
+	if hr.WorkStyle == nil {
+		return unknown(CriterionRemote, "要員の勤務スタイルが未設定")
+	}
+	return CriterionScore{Criterion: CriterionRemote, Score: fit[*hr.WorkStyle], Known: true,
+		Reason: fmt.Sprintf("案件「%s」/ 要員「%s」", *p.RemoteWorkFrequency, *hr.WorkStyle)}
+}
+
+// scoreLocation は案件の勤務地（都道府県）と要員の居住地の一致
+// フルリモート案件は勤務地を問わない
+func scoreLocation(p *project.Project, hr *humanresource.HumanResource) CriterionScore {
+	if p.RemoteWorkFrequency != nil && strings.TrimSpace(*p.RemoteWorkFrequency) == RemoteFull {
+		return CriterionScore{Criterion: CriterionLocation, Score: 1, Known: true, Reason: "フルリモート案件"}
+	}
+	if p.Prefecture == nil || *p.Prefecture == "" {
+		return unknown(CriterionLocation, "案件の勤務地が未設定")
+	}
+	if hr.Residence == nil || *hr.Residence == "" {
+		return unknown(CriterionLocation, "要員の居住地が未設定")
+	}
+	// residence は「{都道府県}-{市区町村}」形式
+	if strings.HasPrefix(*hr.Residence, *p.Prefecture) {
+		return CriterionScore{Criterion: CriterionLocation, Score: 1, Known: true,
+			Reason: fmt.Sprintf("居住地「%s」が勤務地「%s」", *hr.Residence, *p.Prefecture)}
+	}
+	return CriterionScore{Criterion: CriterionLocation, Score: 0.2, Known: true,
+		Reason: fmt.Sprintf("居住地「%s」と勤務地「%s」が異なる", *hr.Residence, *p.Prefecture)}
+}
+
+// upper は範囲の上限（上限が未設定なら下限）を返す（0は未設定扱い）
+func upper(minV, maxV *uint) (uint, bool) {
+	if maxV != nil && *maxV > 0 {
+		return *maxV, true
+	}
+	if minV != nil && *minV > 0 {
+		return *minV, true
+	}
+	return 0, false
+}
+
+// lower は範囲の下限（下限が未設定なら上限）を返す（0は未設定扱い）
+func lower(minV, maxV *uint) (uint, bool) {
+	if minV != nil && *minV > 0 {
+		return *minV, true
+	}
+	if maxV != nil && *maxV > 0 {
+		return *maxV, true
+	}
+	return 0, false
+}
+
+func round(v float64, digits int) float64 {
+	p := math.Pow10(digits)
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,195 @@
+package matching
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"shakehandz-api/internal/humanresource"
+	"shakehandz-api/internal/project"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// scoreOf は内訳から指定した観点の点数を取り出す
+func scoreOf(t *testing.T, s Score, c Criterion) CriterionScore {
+	t.Helper()
+	for _, cs := range s.Breakdown {
+		if cs.Criterion == c {
+			return cs
+		}
+	}
+	t.Fatalf("criterion %s not found in breakdown", c)
+	return CriterionScore{}
+}
+
+func TestEvaluate(t *testing.T) {
+	onSite := humanresource.WorkStyleOnSite
+	fullRemote := humanresource.WorkStyleFullRemote
+
+	tests := []struct {
+		name      string
+		project   project.Project
+		hr        humanresource.HumanResource
+		want      map[Criterion]float64
+		wantTotal float64
+	}{
+		{
+			name: "全観点で一致",
+			project: project.Project{
+				RequiredSkills:      []string{"Go", "AWS"},
+				UnitPriceMax:        ptr(uint(80)),
+				RemoteWorkFrequency: ptr(RemoteOnSite),
+				Prefecture:          ptr("東京都"),
+			},
+			hr: humanresource.HumanResource{
+				MainSkills:     []string{"go", "AWS"},
+				MonthlyRateMin: ptr(uint(70)),
+				WorkStyle:      &onSite,
+				Residence:      ptr("東京都-渋谷区"),
+			},
+			want: map[Criterion]float64{
+				CriterionSkill: 1, CriterionRate: 1, CriterionStart: unknownScore, CriterionRemote: 1, CriterionLocation: 1,
+			},
+			wantTotal: 92.5,
+		},
+		{
+			name:    "情報が無い観点は中間点",
+			project: project.Project{},
+			hr:      humanresource.HumanResource{},
+			want: map[Criterion]float64{
+				CriterionSkill: unknownScore, CriterionRate: unknownScore, CriterionStart: unknownScore, CriterionRemote: unknownScore, CriterionLocation: unknownScore,
+			},
+			wantTotal: 50,
+		},
+		{
+			name: "サブスキル・尚可スキルの一致は重みを下げる",
+			project: project.Project{
+				RequiredSkills:   []string{"Java経験3年以上", "Spring"},
+				NiceToHaveSkills: []string{"AWS"},
+			},
+			hr: humanresource.HumanResource{
+				MainSkills: []string{"Java"},
+				SubSkills:  []string{"Spring", "AWS"},
+			},
+			// (1 + 0.5 + 0.3*0.5) / (2 + 0.3)
+			want: map[Criterion]float64{CriterionSkill: 0.72},
+		},
+		{
+			name:    "英単語の途中では一致しない",
+			project: project.Project{RequiredSkills: []string{"JavaScript"}},
+			hr:      humanresource.HumanResource{MainSkills: []string{"Java"}},
+			want:    map[Criterion]float64{CriterionSkill: 0},
+		},
+		{
+			name:    "要員のスキルが未設定",
+			project: project.Project{RequiredSkills: []string{"Go"}},
+			hr:      humanresource.HumanResource{},
+			want:    map[Criterion]float64{CriterionSkill: 0},
+		},
+		{
+			name:    "希望単価が予算を10%超過",
+			project: project.Project{UnitPriceMin: ptr(uint(50)), UnitPriceMax: ptr(uint(100))},
+			hr:      humanresource.HumanResource{MonthlyRateMin: ptr(uint(110)), MonthlyRateMax: ptr(uint(130))},
+			want:    map[Criterion]float64{CriterionRate: 0.5},
+		},
+		{
+			name:    "希望単価が予算を大きく超過",
+			project: project.Project{UnitPriceMax: ptr(uint(60))},
+			hr:      humanresource.HumanResource{MonthlyRateMin: ptr(uint(80))},
+			want:    map[Criterion]float64{CriterionRate: 0},
+		},
+		{
+			name:    "時給の案件は時給で比較する",
+			project: project.Project{UnitPriceMax: ptr(uint(5000)), UnitPriceUnit: ptr("円/時")},
+			hr:      humanresource.HumanResource{MonthlyRateMin: ptr(uint(90)), HourlyRateMin: ptr(uint(4500))},
+			want:    map[Criterion]float64{CriterionRate: 1},
+		},
+		{
+			name:    "常駐案件にフルリモート希望",
+			project: project.Project{RemoteWorkFrequency: ptr(RemoteOnSite), Prefecture: ptr("大阪府")},
+			hr:      humanresource.HumanResource{WorkStyle: &fullRemote, Residence: ptr("東京都-港区")},
+			want:    map[Criterion]float64{CriterionRemote: 0, CriterionLocation: 0.2},
+		},
+		{
+			name:    "フルリモート案件は勤務地を問わない",
+			project: project.Project{RemoteWorkFrequency: ptr(RemoteFull), Prefecture: ptr("大阪府")},
+			hr:      humanresource.HumanResource{WorkStyle: &onSite, Residence: ptr("東京都-港区")},
+			want:    map[Criterion]float64{CriterionRemote: 1, CriterionLocation: 1},
+		},
+		{
+			name:    "判定できない勤務形態",
+			project: project.Project{RemoteWorkFrequency: ptr("週3出社")},
+			hr:      humanresource.HumanResource{WorkStyle: &onSite},
+			want:    map[Criterion]float64{CriterionRemote: unknownScore},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(&tt.project, &tt.hr)
+
+			for c, want := range tt.want {
+				if cs := scoreOf(t, got, c); math.Abs(cs.Score-want) > 1e-9 {
+					t.Errorf("%s score = %v, want %v (%s)", c, cs.Score, want, cs.Reason)
+				}
+			}
+			if tt.wantTotal != 0 && math.Abs(got.Total-tt.wantTotal) > 1e-9 {
+				t.Errorf("Total = %v, want %v", got.Total, tt.wantTotal)
+			}
+
+			// 重みの合計は1で、合計点は0〜100に収まる
+			sum := 0.0
+			for _, cs := range got.Breakdown {
+				sum += cs.Weight
+			}
+			if math.Abs(sum-1) > 1e-9 {
+				t.Errorf("sum of weights = %v, want 1", sum)
+			}
+			if got.Total < 0 || got.Total > 100 {
+				t.Errorf("Total = %v, want within 0..100", got.Total)
+			}
+		})
+	}
+}
+
+func TestScoreStart(t *testing.T) {
+	// 2026年10月を現在とする
+	orig := now
+	now = func() time.Time { return time.Date(2026, time.October, 15, 9, 0, 0, 0, time.Local) }
+	t.Cleanup(func() { now = orig })
+
+	month := func(year int, m time.Month) *time.Time {
+		v := time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+		return &v
+	}
+
+	tests := []struct {
+		name      string
+		start     *time.Time
+		available []int
+		want      float64
+		wantKnown bool
+	}{
+		{name: "開始月に参画可能", start: month(2026, time.November), available: []int{11}, want: 1, wantKnown: true},
+		{name: "1ヶ月のずれ", start: month(2027, time.January), available: []int{12}, want: 0.67, wantKnown: true},
+		{name: "年をまたぐ開始月", start: month(2027, time.January), available: []int{1}, want: 1, wantKnown: true},
+		{name: "最も近い参画可能月で判定", start: month(2027, time.January), available: []int{3, 12}, want: 0.67, wantKnown: true},
+		{name: "翌年の同じ月は12ヶ月のずれ", start: month(2027, time.October), available: []int{10}, want: 0, wantKnown: true},
+		{name: "過ぎた参画可能月は翌年として数える", start: month(2026, time.October), available: []int{9}, want: 0, wantKnown: true},
+		{name: "即日参画可能", start: month(2028, time.April), available: []int{0, 6}, want: 1, wantKnown: true},
+		{name: "開始月を過ぎた案件", start: month(2026, time.August), available: []int{8}, want: unknownScore},
+		{name: "案件の開始月が未設定", available: []int{11}, want: unknownScore},
+		{name: "参画可能時期が未設定", start: month(2026, time.November), want: unknownScore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := project.Project{ProjectStartMonth: tt.start}
+			hr := humanresource.HumanResource{AvailableStartMonths: tt.available}
+
+			got := scoreOf(t, Evaluate(&p, &hr), CriterionStart)
+			if math.Abs(got.Score-tt.want) > 1e-9 || got.Known != tt.wantKnown {
+				t.Errorf("score = %v (known %v), want %v (known %v): %s", got.Score, got.Known, tt.want, tt.wantKnown, got.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package matching
+
+import (
+	"errors"
+	"slices"
+
+	"shakehandz-api/internal/humanresource"
+	"shakehandz-api/internal/project"
+	"shakehandz-api/internal/shared/ratelimit"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProjectNotFound       = errors.New("project not found")
+	ErrHumanResourceNotFound = errors.New("human resource not found")
+)
+
+const (
+	// 採点対象として読み込む件数の上限（受信日の新しい順）
+	maxCandidates = 1000
+
+	DefaultLimit = 20
+	MaxLimit     = 100
+	// 採点対象とするメールの受信日（過去何日分か）
+	DefaultDays = 30
+	MaxDays     = 365
+)
+
+// Options はランキングの条件です。
+type Options struct {
+	Limit    int
+	MinScore float64
+	Days     int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Limit <= 0 || o.Limit > MaxLimit {
+		o.Limit = DefaultLimit
+	}
+	if o.Days <= 0 || o.Days > MaxDays {
+		o.Days = DefaultDays
+	}
+	return o
+}
+
+// CandidateMatch は案件に対する要員の適合度です。
+type CandidateMatch struct {
+	Score
+	HumanResource humanresource.HumanResource `json:"human_resource"`
+}
+
+// ProjectMatch は要員に対する案件の適合度です。
+type ProjectMatch struct {
+	Score
+	Project project.Project `json:"project"`
+}
+
+type Service struct {
+	DB *gorm.DB
+	// LLMLimiter はユーザーごとのLLM呼び出しのレートリミッター
+	// 抽出処理と同じGeminiの割り当てを消費するため、抽出処理のリミッターを共有する
+	LLMLimiter *ratelimit.KeyedLimiter
+}
+
+func NewService(db *gorm.DB, limiter *ratelimit.KeyedLimiter) *Service {
+	return &Service{
+		DB:         db,
+		LLMLimiter: limiter,
+	}
+}
+
+// MatchCandidates は案件に適合する要員を点数の高い順に返す
+// 同一人物のクラスタは代表の要員のみを対象にする
+func (s *Service) MatchCandidates(userID uuid.UUID, projectID string, opt Options) (*project.Project, []CandidateMatch, error) {
+	opt = opt.withDefaults()
+
+	var p project.Project
+	if err := s.DB.Where("created_by_id = ?", userID).First(&p, "id = ?", projectID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrProjectNotFound
+		}
+		return nil, nil, err
+	}
+
+	var hrs []humanresource.HumanResource
+	err := s.DB.Where("created_by_id = ?", userID).
+		Where("email_received_at >= NOW() - INTERVAL ? DAY", opt.Days).
+		Scopes(humanresource.CanonicalOnly).
+		Order("email_received_at DESC").
+		Limit(maxCandidates).
+		Find(&hrs).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	matches := make([]CandidateMatch, 0, len(hrs))
+	for i := range hrs {
+		sc := Evaluate(&p, &hrs[i])
+		if sc.Total < opt.MinScore {
+			continue
+		}
+		matches = append(matches, CandidateMatch{Score: sc, HumanResource: hrs[i]})
+	}
+	slices.SortStableFunc(matches, func(a, b CandidateMatch) int { return compareTotal(a.Score, b.Score) })
+
+	return &p, matches[:min(len(matches), opt.Limit)], nil
+}
+
+// MatchProjects は要員に適合する案件を点数の高い順に返す
+func (s *Service) MatchProjects(userID uuid.UUID, hrID string, opt Options) (*humanresource.HumanResource, []ProjectMatch, error) {
+	opt = opt.withDefaults()
+
+	var hr humanresource.HumanResource
+	if err := s.DB.Where("created_by_id = ?", userID).First(&hr, "id = ?", hrID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrHumanResourceNotFound
+		}
+		return nil, nil, err
+	}
+
+	var projects []project.Project
+	err := s.DB.Where("created_by_id = ?", userID).
+		Where("email_received_at >= NOW() - INTERVAL ? DAY", opt.Days).
+		Order("email_received_at DESC").
+		Limit(maxCandidates).
+		Find(&projects).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	matches := make([]ProjectMatch, 0, len(projects))
+	for i := range projects {
+		sc := Evaluate(&projects[i], &hr)
+		if sc.Total < opt.MinScore {
+			continue
+		}
+		matches = append(matches, ProjectMatch{Score: sc, Project: projects[i]})
+	}
+	slices.SortStableFunc(matches, func(a, b ProjectMatch) int { return compareTotal(a.Score, b.Score) })
+
+	return &hr, matches[:min(len(matches), opt.Limit)], nil
+}
+
+// compareTotal は点数の降順に並べる
+func compareTotal(a, b Score) int {
+	switch {
+	case a.Total > b.Total:
+		return -1
+	case a.Total < b.Total:
+		return 1
+	default:
+		return 0
+	}
+}
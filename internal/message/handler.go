@@ -3,7 +3,10 @@ package message
 import (
 	"net/http"
 
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
 	oauth "shakehandz-api/internal/shared/auth/oauth"
+	"shakehandz-api/internal/shared/response"
 
 	"shakehandz-api/internal/shared/message/gmail"
 
@@ -31,6 +34,14 @@ func MessageHandler(svc *MessageService, db *gorm.DB) gin.HandlerFunc {
 
 		msgs, err := svc.Run(ctx, gmail_svc, c.Query("query"), 0)
 		if err != nil {
+			// refresh_tokenが失効している場合は失効済みにして再同意を促す
+			if auth.HandleTokenError(db, verified.User.ID, err) {
+				response.SendError(c, apierror.Auth.TokenExpired, response.ErrorDetail{
+					Detail:   "google refresh token has been revoked",
+					Resource: "oauth token",
+				})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch messages"})
 			return
 		}
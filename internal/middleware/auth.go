@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"shakehandz-api/internal/auth" // User, OauthTokenモデルのパス
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/response"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -35,6 +37,15 @@ func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// refresh_tokenが失効している場合はフロントエンドに再同意を促す
+		if token.IsRevoked() {
+			response.SendError(c, apierror.Auth.TokenExpired, response.ErrorDetail{
+				Detail:   "google refresh token has been revoked",
+				Resource: "oauth token",
+			})
+			return
+		}
+
 		if token.UserID == uuid.Nil {
 			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "user id not associated with the token"})
 			return
@@ -0,0 +1,97 @@
+package project
+
+import (
+	"log"
+	"net/http"
+	"slices"
+
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/audit"
+	"shakehandz-api/internal/shared/options"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// editableFields は手動で修正・差し戻しできる項目（JSONキーとカラム名は同一）
+var editableFields = map[string]bool{
+	"project_start_month":        true,
+	"prefecture":                 true,
+	"work_location":              true,
+	"remote_work_frequency":      true,
+	"working_hours":              true,
+	"required_skills":            true,
+	"nice_to_have_skills":        true,
+	"unit_price_min":             true,
+	"unit_price_max":             true,
+	"unit_price_unit":            true,
+	"business_flow":              true,
+	"business_flow_restrictions": true,
+	"priority_talent":            true,
+	"project_summary":            true,
+}
+
+// PATCH /projects/:id
+// 案件の指定した項目のみを修正する
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+		return
+	}
+
+	p, ok := h.findProject(c)
+	if !ok {
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		response.SendError(c, apierror.Common.InvalidRequest, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+		return
+	}
+
+	if !h.applyUpdate(c, p, body, audit.ActionUpdate, user.ID) {
+		return
+	}
+
+	response.SendSuccess(c, http.StatusOK, p)
+}
+
+// applyUpdate は body の項目を案件に反映して保存し、変更履歴を記録する
+// 失敗した場合はエラーレスポンスを送信して false を返す
+func (h *ProjectHandler) applyUpdate(c *gin.Context, p *Project, body []byte, action audit.Action, userID uuid.UUID) bool {
+	beforeSkills := p.Skills()
+
+	p.UpdatedByID = &userID
+	patch := audit.Patch{
+		ResourceType: audit.ResourceProject,
+		ResourceID:   p.ID,
+		Editable:     editableFields,
+		Validate:     p.Validate,
+	}
+	if !audit.ApplyPatch(c, h.DB, p, body, patch, action, audit.Manual(userID)) {
+		return false
+	}
+
+	// 新たに追加されたスキルのみ選択肢に反映する
+	var added []string
+	for _, s := range p.Skills() {
+		if !slices.Contains(beforeSkills, s) {
+			added = append(added, s)
+		}
+	}
+	if err := options.SaveSkills(h.DB, added); err != nil {
+		log.Printf("スキルの保存に失敗しました: %v", err)
+	}
+
+	return true
+}
@@ -0,0 +1,168 @@
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 参画開始月の入力形式
+const startMonthLayout = "2006-01"
+
+// スキルの検索方法
+const (
+	SkillMatchAnd = "and"
+	SkillMatchOr  = "or"
+)
+
+// フィルターパラメータを解析する関数
+func (h *ProjectHandler) parseFilterParams(c *gin.Context) (*ProjectFilter, error) {
+	var filter ProjectFilter
+
+	// Content-TypeがJSONの場合（POSTリクエスト）
+	if c.ContentType() == "application/json" {
+		if err := c.ShouldBindJSON(&filter); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON body: %v", err)
+		}
+		return &filter, nil
+	}
+
+	// クエリパラメータから基本的な値を取得（prefecture などは同じキーを繰り返して指定）
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		return nil, fmt.Errorf("failed to parse query params: %v", err)
+	}
+
+	// 単価範囲の解析（例: unit_price=[50,80]）
+	filter.UnitPrice = nil
+	if priceParam := c.Query("unit_price"); priceParam != "" {
+		var prices []int
+		if err := json.Unmarshal([]byte(priceParam), &prices); err == nil && len(prices) == 2 {
+			filter.UnitPrice = prices
+		}
+	}
+
+	// 必須スキル・尚可スキルの解析（例: required_skills=["Java","AWS"]）
+	filter.RequiredSkills = parseSkillsParam(c, "required_skills")
+	filter.NiceToHaveSkills = parseSkillsParam(c, "nice_to_have_skills")
+
+	return &filter, nil
+}
+
+func (h *ProjectHandler) applyFilters(query *gorm.DB, filter *ProjectFilter) *gorm.DB {
+	// フリーワード検索（件名、勤務地、スキル、概要などを横断検索）
+	if filter.FreeWord != "" {
+		searchTerm := "%" + filter.FreeWord + "%"
+		query = query.Where("email_subject LIKE ? OR prefecture LIKE ? OR work_location LIKE ? OR required_skills LIKE ? OR nice_to_have_skills LIKE ? OR priority_talent LIKE ? OR business_flow LIKE ? OR project_summary LIKE ?",
+			searchTerm, searchTerm, searchTerm, searchTerm, searchTerm, searchTerm, searchTerm, searchTerm)
+	}
+
+	// 単価範囲検索（上限が無い案件は下限で判定）
+	if len(filter.UnitPrice) == 2 {
+		query = query.Where("COALESCE(unit_price_max, unit_price_min) BETWEEN ? AND ?", filter.UnitPrice[0], filter.UnitPrice[1])
+	}
+
+	// 都道府県検索（IN句）
+	if len(filter.Prefecture) > 0 {
+		query = query.Where("prefecture IN ?", filter.Prefecture)
+	}
+
+	// リモート頻度検索（IN句）
+	if len(filter.RemoteWorkFrequency) > 0 {
+		query = query.Where("remote_work_frequency IN ?", filter.RemoteWorkFrequency)
+	}
+
+	// 参画開始月（validateFilter で形式を検証済み）
+	if from, err := time.ParseInLocation(startMonthLayout, filter.StartMonthFrom, time.Local); err == nil {
+		query = query.Where("project_start_month >= ?", from)
+	}
+	if to, err := time.ParseInLocation(startMonthLayout, filter.StartMonthTo, time.Local); err == nil {
+		query = query.Where("project_start_month < ?", to.AddDate(0, 1, 0))
+	}
+
+	// 必須スキル・尚可スキル検索
+	query = h.applySkillFilter(query, "required_skills", filter.RequiredSkills, filter.SkillMatch)
+	query = h.applySkillFilter(query, "nice_to_have_skills", filter.NiceToHaveSkills, filter.SkillMatch)
+
+	if filter.ReceiveAt != "" {
+		query = query.Where("email_received_at >= ?", filter.ReceiveAt)
+	}
+
+	return query
+}
+
+// スキルフィルターを適用する関数
+// and: すべてのスキルを含む案件 / or: いずれかのスキルを含む案件
+func (h *ProjectHandler) applySkillFilter(query *gorm.DB, columnName string, skillFilter []string, match string) *gorm.DB {
+	if len(skillFilter) == 0 {
+		return query
+	}
+
+	conditions := make([]string, 0, len(skillFilter))
+	args := make([]any, 0, len(skillFilter))
+	for _, skill := range skillFilter {
+		// スキル名に " などが含まれても壊れないようにJSONとして組み立てる
+		candidate, _ := json.Marshal([]string{skill})
+		conditions = append(conditions, "JSON_CONTAINS("+columnName+", ?)")
+		args = append(args, string(candidate))
+	}
+
+	if match == SkillMatchOr {
+		return query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+	for i := range conditions {
+		query = query.Where(conditions[i], args[i])
+	}
+	return query
+}
+
+// parseSkillsParam はJSON配列形式のスキルのクエリパラメータを解析する
+func parseSkillsParam(c *gin.Context, name string) []string {
+	param := c.Query(name)
+	if param == "" {
+		return nil
+	}
+	var skills []string
+	if err := json.Unmarshal([]byte(param), &skills); err != nil {
+		return nil
+	}
+	return skills
+}
+
+// フィルター条件のバリデーション
+func (h *ProjectHandler) validateFilter(filter *ProjectFilter) error {
+	// 単価範囲のバリデーション
+	if len(filter.UnitPrice) == 2 && filter.UnitPrice[0] > filter.UnitPrice[1] {
+		return errors.New("invalid unit price range: min price cannot be greater than max price")
+	}
+
+	// 参画開始月のバリデーション
+	for name, v := range map[string]string{"start_month_from": filter.StartMonthFrom, "start_month_to": filter.StartMonthTo} {
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse(startMonthLayout, v); err != nil {
+			return fmt.Errorf("invalid %s: %q (want YYYY-MM)", name, v)
+		}
+	}
+	if filter.StartMonthFrom != "" && filter.StartMonthTo != "" && filter.StartMonthFrom > filter.StartMonthTo {
+		return errors.New("invalid start month range: from cannot be after to")
+	}
+
+	// スキル選択のバリデーション
+	if len(filter.RequiredSkills) > 10 {
+		return errors.New("too many required skills selected (max: 10)")
+	}
+	if len(filter.NiceToHaveSkills) > 10 {
+		return errors.New("too many nice-to-have skills selected (max: 10)")
+	}
+	if filter.SkillMatch != "" && filter.SkillMatch != SkillMatchAnd && filter.SkillMatch != SkillMatchOr {
+		return fmt.Errorf("invalid skill_match: %q (want %s or %s)", filter.SkillMatch, SkillMatchAnd, SkillMatchOr)
+	}
+
+	return nil
+}
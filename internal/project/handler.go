@@ -3,6 +3,10 @@ package project
 import (
 	"net/http"
 
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/response"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -15,23 +19,75 @@ func NewProjectHandler(db *gorm.DB) *ProjectHandler {
 	return &ProjectHandler{DB: db}
 }
 
-// GET /projects
+// GET /projects, POST /projects
+// ログインユーザーの案件をフィルター・ページングして返す
 func (h *ProjectHandler) GetProjects(c *gin.Context) {
 	var projects []Project
-	if err := h.DB.Find(&projects).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "取得失敗"})
+	var total int64
+
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
 		return
 	}
-	c.JSON(http.StatusOK, projects)
+
+	// フィルターパラメータを解析
+	filter, err := h.parseFilterParams(c)
+	if err == nil {
+		err = h.validateFilter(filter)
+	}
+	if err != nil {
+		response.SendError(c, apierror.Common.BadRequest, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+		return
+	}
+
+	// デフォルト値を設定
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	// ベースクエリを構築し、動的フィルターを適用
+	query := h.applyFilters(h.DB.Model(&Project{}).Where("created_by_id = ?", user.ID), filter)
+
+	// 総数を取得（ページング用）
+	if err := query.Count(&total).Error; err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+		return
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("email_received_at DESC").Offset(offset).Limit(filter.Limit).Find(&projects).Error; err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+		return
+	}
+
+	response.SendSuccess(c, http.StatusOK, ProjectResponse{
+		Pagination:     response.NewPaginationInfo(filter.Page, filter.Limit, total),
+		AppliedFilters: filter,
+		ProjectsData:   projects,
+	})
 }
 
 // GET /projects/:id
 func (h *ProjectHandler) GetProject(c *gin.Context) {
-	id := c.Param("id")
-	var project Project
-	if err := h.DB.First(&project, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "見つかりません"})
+	p, ok := h.findProject(c)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, project)
+	response.SendSuccess(c, http.StatusOK, p)
 }
@@ -0,0 +1,124 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/audit"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /projects/:id/history
+func (h *ProjectHandler) GetProjectHistory(c *gin.Context) {
+	p, ok := h.findProject(c)
+	if !ok {
+		return
+	}
+
+	logs, err := audit.History(h.DB, audit.ResourceProject, p.ID)
+	if err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+		return
+	}
+
+	response.SendSuccess(c, http.StatusOK, logs)
+}
+
+// POST /projects/:id/history/:logId/revert
+// 変更履歴の項目を変更前の値に戻す
+func (h *ProjectHandler) RevertProjectField(c *gin.Context) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+		return
+	}
+
+	logID, err := strconv.ParseUint(c.Param("logId"), 10, 64)
+	if err != nil {
+		response.SendError(c, apierror.Common.InvalidRequest, response.ErrorDetail{
+			Detail:   "invalid log id",
+			Resource: "project",
+			Field:    "logId",
+		})
+		return
+	}
+
+	p, ok := h.findProject(c)
+	if !ok {
+		return
+	}
+
+	entry, err := audit.FindFieldChange(h.DB, audit.ResourceProject, p.ID, uint(logID))
+	if err != nil {
+		if errors.Is(err, audit.ErrNotFound) {
+			response.SendError(c, apierror.Audit.LogNotFound, response.ErrorDetail{
+				Detail:   err.Error(),
+				Resource: "project",
+			})
+			return
+		}
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+		return
+	}
+
+	body, err := entry.RevertBody()
+	if err != nil {
+		response.SendError(c, apierror.Common.Unknown, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+		return
+	}
+
+	if !h.applyUpdate(c, p, body, audit.ActionRevert, user.ID) {
+		return
+	}
+
+	response.SendSuccess(c, http.StatusOK, p)
+}
+
+// findProject はログインユーザーの案件をパスパラメータのIDで取得する
+// 見つからない場合はエラーレスポンスを送信して ok=false を返す
+func (h *ProjectHandler) findProject(c *gin.Context) (*Project, bool) {
+	user, err := auth.GetUser(c)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unauthorized, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+		return nil, false
+	}
+
+	var p Project
+	if err := h.DB.Where("created_by_id = ?", user.ID).First(&p, "id = ?", c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.SendError(c, apierror.Project.NotFound, response.ErrorDetail{
+				Detail:   fmt.Sprintf("project %s not found", c.Param("id")),
+				Resource: "project",
+			})
+			return nil, false
+		}
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: "project",
+		})
+		return nil, false
+	}
+	return &p, true
+}
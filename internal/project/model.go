@@ -1,27 +1,79 @@
 package project
 
-import "time"
+import (
+	"shakehandz-api/internal/auth"
+	"shakehandz-api/internal/shared/response"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
 
 type Project struct {
-	ID                       string     `gorm:"primaryKey" json:"id"`
-	EmailID                  string     `gorm:"type:varchar(255)" json:"email_id"`
-	EmailSubject             *string    `json:"email_subject,omitempty"`
-	EmailSender              *string    `json:"email_sender,omitempty"`
-	EmailReceivedAt          *time.Time `json:"email_received_at,omitempty"`
-	ProjectStartMonth        *time.Time `json:"project_start_month,omitempty"`
-	Prefecture               *string    `gorm:"type:varchar(255)" json:"prefecture,omitempty"`
-	WorkLocation             *string    `json:"work_location,omitempty"`
-	RemoteWorkFrequency      *string    `json:"remote_work_frequency,omitempty"`
-	WorkingHours             *string    `json:"working_hours,omitempty"`
-	RequiredSkills           *string    `json:"required_skills,omitempty"`
-	UnitPriceMin             *uint      `json:"unit_price_min,omitempty"`
-	UnitPriceMax             *uint      `json:"unit_price_max,omitempty"`
-	UnitPriceUnit            *string    `json:"unit_price_unit,omitempty"`
-	BusinessFlow             *string    `json:"business_flow,omitempty"`
-	BusinessFlowRestrictions *string    `json:"business_flow_restrictions,omitempty"`
-	PriorityTalent           *string    `json:"priority_talent,omitempty"`
-	ProjectSummary           *string    `json:"project_summary,omitempty"`
-	RegisteredAt             *time.Time `json:"registered_at,omitempty"`
-	ExtractionConfidence     *float64   `json:"extraction_confidence,omitempty"`
-	ExtractionNotes          *string    `json:"extraction_notes,omitempty"`
+	ID                       string                      `gorm:"primaryKey" json:"id"`
+	EmailID                  string                      `gorm:"type:varchar(255);index" json:"email_id"`
+	EmailSubject             *string                     `json:"email_subject,omitempty"`
+	EmailSender              *string                     `json:"email_sender,omitempty"`
+	EmailReceivedAt          *time.Time                  `json:"email_received_at,omitempty"`
+	ProjectStartMonth        *time.Time                  `json:"project_start_month,omitempty"`
+	Prefecture               *string                     `gorm:"type:varchar(255)" json:"prefecture,omitempty"`
+	WorkLocation             *string                     `json:"work_location,omitempty"`
+	RemoteWorkFrequency      *string                     `json:"remote_work_frequency,omitempty"`
+	WorkingHours             *string                     `json:"working_hours,omitempty"`
+	RequiredSkills           datatypes.JSONSlice[string] `gorm:"type:json" json:"required_skills,omitempty"`
+	NiceToHaveSkills         datatypes.JSONSlice[string] `gorm:"type:json" json:"nice_to_have_skills,omitempty"`
+	UnitPriceMin             *uint                       `json:"unit_price_min,omitempty"`
+	UnitPriceMax             *uint                       `json:"unit_price_max,omitempty"`
+	UnitPriceUnit            *string                     `json:"unit_price_unit,omitempty"`
+	BusinessFlow             *string                     `json:"business_flow,omitempty"`
+	BusinessFlowRestrictions *string                     `json:"business_flow_restrictions,omitempty"`
+	PriorityTalent           *string                     `json:"priority_talent,omitempty"`
+	ProjectSummary           *string                     `json:"project_summary,omitempty"`
+	RegisteredAt             *time.Time                  `json:"registered_at,omitempty"`
+	ExtractionConfidence     *float64                    `json:"extraction_confidence,omitempty"`
+	ExtractionNotes          *string                     `json:"extraction_notes,omitempty"`
+
+	/* メタ情報 */
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CreatedByID *uuid.UUID `gorm:"type:char(36);index" json:"created_by_id,omitempty"`
+	UpdatedByID *uuid.UUID `gorm:"type:char(36)" json:"updated_by_id,omitempty"`
+
+	Creator *auth.User `gorm:"foreignKey:CreatedByID;references:ID" json:"creator,omitempty"`
+	Updater *auth.User `gorm:"foreignKey:UpdatedByID;references:ID" json:"updater,omitempty"`
+}
+
+type ProjectFilter struct {
+	// テキスト入力
+	FreeWord string `form:"free_word" json:"free_word"`
+
+	// 数値範囲（配列形式）
+	UnitPrice []int `form:"-" json:"unit_price"` // 「[50,80]」形式のためバインドせず手動で解析する
+
+	// チェックボックス（文字列配列）
+	Prefecture          []string `form:"prefecture" json:"prefecture"`
+	RemoteWorkFrequency []string `form:"remote_work_frequency" json:"remote_work_frequency"`
+
+	// 参画開始月の範囲（YYYY-MM）
+	StartMonthFrom string `form:"start_month_from" json:"start_month_from"`
+	StartMonthTo   string `form:"start_month_to" json:"start_month_to"`
+
+	// セレクト
+	RequiredSkills   []string `json:"required_skills"`
+	NiceToHaveSkills []string `json:"nice_to_have_skills"`
+	// スキルの検索方法（and: すべて含む / or: いずれかを含む。省略時は and）
+	SkillMatch string `form:"skill_match" json:"skill_match"`
+
+	// 最もふるい受信日
+	ReceiveAt string `form:"receive_at" json:"receive_at"`
+
+	// ページング用
+	Page  int `form:"page" json:"page"`
+	Limit int `form:"limit" json:"limit"`
+}
+
+type ProjectResponse struct {
+	Pagination     response.PaginationInfo `json:"pagination"`
+	AppliedFilters *ProjectFilter          `json:"appliedFilters"`
+	ProjectsData   []Project               `json:"projectsData"`
 }
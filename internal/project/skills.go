@@ -0,0 +1,82 @@
+package project
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// legacySkillSeparator は旧形式の必須スキル（「、」区切りのテキスト）の区切り（表記揺れにも対応する）
+var legacySkillSeparator = regexp.MustCompile(`[、,，/／・\n]+`)
+
+// splitSkills は「、」区切りのスキルのテキストを一覧にする
+func splitSkills(text string) []string {
+	var out []string
+	for _, s := range legacySkillSeparator.Split(text, -1) {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Skills は案件の必須スキルと尚可スキルをまとめて返す（スキルの選択肢の更新用）
+func (p *Project) Skills() []string {
+	return append(append([]string{}, p.RequiredSkills...), p.NiceToHaveSkills...)
+}
+
+// MigrateLegacySkills は旧形式（テキスト）の required_skills をJSON配列に変換する
+// JSON型への変更（AutoMigrate）より前に実行し、変換した件数を返す
+func MigrateLegacySkills(db *gorm.DB) (int, error) {
+	m := db.Migrator()
+	if !m.HasTable(&Project{}) || !m.HasColumn(&Project{}, "required_skills") {
+		return 0, nil
+	}
+	columns, err := m.ColumnTypes(&Project{})
+	if err != nil {
+		return 0, err
+	}
+	for _, col := range columns {
+		if col.Name() == "required_skills" && strings.EqualFold(col.DatabaseTypeName(), "json") {
+			return 0, nil
+		}
+	}
+
+	var rows []struct {
+		ID             string
+		RequiredSkills string
+	}
+	if err := db.Table("projects").Select("id, required_skills").Where("required_skills IS NOT NULL").Find(&rows).Error; err != nil {
+		return 0, err
+	}
+
+	converted := 0
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			var skills []string
+			if json.Unmarshal([]byte(row.RequiredSkills), &skills) == nil {
+				continue
+			}
+			// 空のテキストはJSONとして不正なためNULLにする
+			var value any
+			if skills = splitSkills(row.RequiredSkills); len(skills) > 0 {
+				b, err := json.Marshal(skills)
+				if err != nil {
+					return err
+				}
+				value = string(b)
+			}
+			if err := tx.Table("projects").Where("id = ?", row.ID).Update("required_skills", value).Error; err != nil {
+				return err
+			}
+			converted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return converted, nil
+}
@@ -0,0 +1,25 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validate は抽出結果や入力値がDBの定義・業務上の範囲に収まっているかを検証する
+func (p *Project) Validate() error {
+	if strings.TrimSpace(p.EmailID) == "" {
+		return errors.New("email_id is required")
+	}
+	if p.UnitPriceMin != nil && p.UnitPriceMax != nil && *p.UnitPriceMin > 0 && *p.UnitPriceMax > 0 && *p.UnitPriceMin > *p.UnitPriceMax {
+		return fmt.Errorf("invalid unit price range: min %d is greater than max %d", *p.UnitPriceMin, *p.UnitPriceMax)
+	}
+	if p.ExtractionConfidence != nil && (*p.ExtractionConfidence < 0 || *p.ExtractionConfidence > 1) {
+		return fmt.Errorf("extraction_confidence out of range: %f", *p.ExtractionConfidence)
+	}
+	if p.Prefecture != nil && utf8.RuneCountInString(*p.Prefecture) > 255 {
+		return errors.New("prefecture too long (max: 255)")
+	}
+	return nil
+}
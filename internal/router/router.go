@@ -1,9 +1,12 @@
 package router
 
 import (
+	"context"
+	"log"
 	"shakehandz-api/internal/auth"
 	"shakehandz-api/internal/extractor"
 	"shakehandz-api/internal/humanresource"
+	"shakehandz-api/internal/matching"
 	message "shakehandz-api/internal/message"
 	"shakehandz-api/internal/middleware"
 	"shakehandz-api/internal/project"
@@ -22,7 +25,7 @@ func SetupRouter(rdb *redis.Client) *gin.Engine {
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-App-Auth"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
@@ -38,9 +41,24 @@ func SetupRouter(rdb *redis.Client) *gin.Engine {
 	authService := auth.NewAuthService(db)
 	hrHandler := humanresource.NewHumanResourcesHandler(db)
 	projectHandler := project.NewProjectHandler(db)
+	matchingService := matching.NewService(db, extractorService.LLMLimiter)
 
 	optionsHandler := options.NewOptionsHandler(db)
 
+	// EXTRACTOR_INPROCESS_WORKER=true の場合は抽出ジョブのワーカーをサーバープロセス内で起動
+	// それ以外はジョブの登録のみ行い、cmd/worker に処理を任せる
+	if workerCfg := extractor.WorkerConfigFromEnv(); workerCfg.InProcess {
+		go func() {
+			ctx := context.Background()
+			if err := extractorService.RecoverPendingBatches(ctx); err != nil {
+				log.Printf("待機中バッチの再登録に失敗しました: %v", err)
+			}
+			extractorService.NewWorker(workerCfg.Concurrency).Run(ctx)
+		}()
+	} else if rdb == nil {
+		log.Printf("警告: REDIS_ADDR が未設定のため cmd/worker と進捗・キャンセル状態を共有できません。抽出ジョブを処理するには EXTRACTOR_INPROCESS_WORKER=true を指定してください")
+	}
+
 	protected := r.Group("/api")
 	protected.Use(middleware.AuthMiddleware(db))
 	{
@@ -49,14 +67,47 @@ func SetupRouter(rdb *redis.Client) *gin.Engine {
 
 		// AI
 		protected.POST("/structure/humanresource", extractor.RefreshExtractorTokenHandler(extractorService))
+		protected.GET("/structure/humanresource/status", extractor.ExtractionStatusHandler(extractorService, extractor.TypeHumanResource))
+		protected.GET("/structure/humanresource/events", extractor.ExtractionEventsHandler(extractorService, extractor.TypeHumanResource))
+		protected.POST("/structure/humanresource/cancel", extractor.CancelExtractionHandler(extractorService, extractor.TypeHumanResource))
+		protected.GET("/structure/humanresource/settings", extractor.GetExtractorSettingHandler(extractorService, extractor.TypeHumanResource))
+		protected.PUT("/structure/humanresource/settings", extractor.UpdateExtractorSettingHandler(extractorService, extractor.TypeHumanResource))
+		protected.POST("/structure/project", extractor.RefreshProjectExtractorTokenHandler(extractorService))
+		protected.GET("/structure/project/status", extractor.ExtractionStatusHandler(extractorService, extractor.TypeProject))
+		protected.GET("/structure/project/events", extractor.ExtractionEventsHandler(extractorService, extractor.TypeProject))
+		protected.POST("/structure/project/cancel", extractor.CancelExtractionHandler(extractorService, extractor.TypeProject))
+		protected.GET("/structure/project/settings", extractor.GetExtractorSettingHandler(extractorService, extractor.TypeProject))
+		protected.PUT("/structure/project/settings", extractor.UpdateExtractorSettingHandler(extractorService, extractor.TypeProject))
+		protected.GET("/structure/batches", extractor.ListExtractorBatchesHandler(extractorService))
+		protected.GET("/structure/batches/:id", extractor.GetExtractorBatchHandler(extractorService))
+		protected.GET("/structure/failures", extractor.ListExtractionFailuresHandler(extractorService))
+		protected.POST("/structure/failures/:id/requeue", extractor.RequeueExtractionFailureHandler(extractorService))
 
 		// 要員管理
 		protected.GET("/humanresource/:id", hrHandler.GetHumanResourceByID)
 		protected.POST("/humanresource", hrHandler.GetHumanResourcesWithFilter)
+		protected.POST("/humanresource/create", hrHandler.CreateHumanResource)
+		protected.GET("/humanresource/clusters", hrHandler.GetClusters)
+		protected.GET("/humanresource/clusters/:clusterId", hrHandler.GetCluster)
+		protected.POST("/humanresource/clusters/merge", hrHandler.MergeClusters)
+		protected.POST("/humanresource/clusters/:clusterId/split", hrHandler.SplitCluster)
+		protected.PATCH("/humanresource/:id", hrHandler.UpdateHumanResource)
+		protected.DELETE("/humanresource/:id", hrHandler.DeleteHumanResource)
+		protected.POST("/humanresource/:id/restore", hrHandler.RestoreHumanResource)
+		protected.GET("/humanresource/:id/history", hrHandler.GetHumanResourceHistory)
+		protected.POST("/humanresource/:id/history/:logId/revert", hrHandler.RevertHumanResourceField)
+		protected.GET("/humanresource/:id/matches", matching.HumanResourceMatchesHandler(matchingService))
 
 		// 案件管理
 		protected.GET("/projects", projectHandler.GetProjects)
+		protected.POST("/projects", projectHandler.GetProjects)
 		protected.GET("/projects/:id", projectHandler.GetProject)
+		protected.PATCH("/projects/:id", projectHandler.UpdateProject)
+		protected.GET("/projects/:id/history", projectHandler.GetProjectHistory)
+		protected.POST("/projects/:id/history/:logId/revert", projectHandler.RevertProjectField)
+		protected.GET("/projects/:id/matches", matching.ProjectMatchesHandler(matchingService))
+		protected.GET("/projects/:id/matches/:hrId/proposal", matching.GetProposalHandler(matchingService))
+		protected.POST("/projects/:id/matches/:hrId/proposal", matching.GenerateProposalHandler(matchingService))
 
 		// 選択肢取得系
 		protected.GET("/options/skills", optionsHandler.GetSkills)
@@ -75,11 +75,51 @@ var Gmail = GmailErrors{
 type extractorErrors struct {
 	Unknown                       Code
 	FetchUnprocessedMessageFailed Code
+	FailureNotFound               Code
+	BatchNotFound                 Code
 }
 
 var Extractor = extractorErrors{
 	Unknown:                       "EX00_0001",
 	FetchUnprocessedMessageFailed: "EX01_0001",
+	FailureNotFound:               "EX02_0001",
+	BatchNotFound:                 "EX02_0002",
+}
+
+type humanResourceErrors struct {
+	NotFound        Code
+	ClusterNotFound Code
+}
+
+var HumanResource = humanResourceErrors{
+	NotFound:        "HR01_0001",
+	ClusterNotFound: "HR01_0002",
+}
+
+type projectErrors struct {
+	NotFound Code
+}
+
+var Project = projectErrors{
+	NotFound: "PJ01_0001",
+}
+
+type matchingErrors struct {
+	ProposalFailed   Code
+	ProposalNotFound Code
+}
+
+var Matching = matchingErrors{
+	ProposalFailed:   "MT01_0001",
+	ProposalNotFound: "MT01_0002",
+}
+
+type auditErrors struct {
+	LogNotFound Code
+}
+
+var Audit = auditErrors{
+	LogNotFound: "AL01_0001",
 }
 
 type optionsErrors struct {
@@ -128,6 +168,22 @@ var errorMap = map[Code]ErrorInfo{
 	// Extractor関連エラー
 	Extractor.Unknown:                       {http.StatusInternalServerError, "不明なエラーが発生しました。"},
 	Extractor.FetchUnprocessedMessageFailed: {http.StatusInternalServerError, "未処理メッセージの取得処理でエラーが発生しました。"},
+	Extractor.FailureNotFound:               {http.StatusNotFound, "解析失敗の記録が見つかりませんでした。"},
+	Extractor.BatchNotFound:                 {http.StatusNotFound, "バッチの実行記録が見つかりませんでした。"},
+
+	// HumanResource関連エラー
+	HumanResource.NotFound:        {http.StatusNotFound, "要員が見つかりませんでした。"},
+	HumanResource.ClusterNotFound: {http.StatusNotFound, "同一人物のクラスタが見つかりませんでした。"},
+
+	// Project関連エラー
+	Project.NotFound: {http.StatusNotFound, "案件が見つかりませんでした。"},
+
+	// Matching関連エラー
+	Matching.ProposalFailed:   {http.StatusBadGateway, "提案文の生成に失敗しました。"},
+	Matching.ProposalNotFound: {http.StatusNotFound, "提案文が見つかりませんでした。"},
+
+	// Audit関連エラー
+	Audit.LogNotFound: {http.StatusNotFound, "変更履歴が見つかりませんでした。"},
 
 	// Options関連エラー
 	Options.SaveSkillDataFailed: {http.StatusInternalServerError, "スキルオプションデータの保存に失敗しました。"},
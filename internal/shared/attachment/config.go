@@ -0,0 +1,55 @@
+// Package attachment は、メール添付ファイル（スキルシート等）からテキストを抽出します。
+package attachment
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// 添付ファイルのサイズ上限のデフォルト（10MB）
+	DefaultMaxBytes = 10 * 1024 * 1024
+	// 1ファイルあたりの抽出文字数上限のデフォルト
+	DefaultMaxTextChars = 20000
+)
+
+// Config は添付ファイルのテキスト抽出設定です。
+type Config struct {
+	// これを超えるサイズの添付ファイルはダウンロードしない
+	MaxBytes int64
+	// 抽出したテキストはこの文字数で切り詰める
+	MaxTextChars int
+	// 種別ごとの有効/無効
+	EnablePDF  bool
+	EnableXLSX bool
+	EnableDOCX bool
+}
+
+// ConfigFromEnv は環境変数から設定を読み込みます。未設定の項目はデフォルト値を利用します。
+//
+//	ATTACHMENT_MAX_BYTES, ATTACHMENT_MAX_TEXT_CHARS,
+//	ATTACHMENT_ENABLE_PDF, ATTACHMENT_ENABLE_XLSX, ATTACHMENT_ENABLE_DOCX
+func ConfigFromEnv() Config {
+	return Config{
+		MaxBytes:     int64(envInt("ATTACHMENT_MAX_BYTES", DefaultMaxBytes)),
+		MaxTextChars: envInt("ATTACHMENT_MAX_TEXT_CHARS", DefaultMaxTextChars),
+		EnablePDF:    envBool("ATTACHMENT_ENABLE_PDF", true),
+		EnableXLSX:   envBool("ATTACHMENT_ENABLE_XLSX", true),
+		EnableDOCX:   envBool("ATTACHMENT_ENABLE_DOCX", true),
+	}
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key))); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+func envBool(key string, def bool) bool {
+	if v, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(key))); err == nil {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,119 @@
+package attachment
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnsupported は対応していないファイル形式のエラーです。
+	ErrUnsupported = errors.New("attachment: unsupported file type")
+	// ErrDisabled は設定で無効化されたファイル形式のエラーです。
+	ErrDisabled = errors.New("attachment: file type disabled")
+	// ErrTooLarge はサイズ上限を超えたファイルのエラーです。
+	ErrTooLarge = errors.New("attachment: file too large")
+)
+
+// Kind は添付ファイルの種別です。
+type Kind string
+
+const (
+	KindPDF  Kind = "pdf"
+	KindXLSX Kind = "xlsx"
+	KindDOCX Kind = "docx"
+)
+
+// KindOf はファイル名の拡張子から種別を判定します。
+func KindOf(filename string) (Kind, bool) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf":
+		return KindPDF, true
+	case ".xlsx", ".xlsm":
+		return KindXLSX, true
+	case ".docx":
+		return KindDOCX, true
+	}
+	return "", false
+}
+
+// Check はダウンロード前に抽出対象かどうかを判定します。
+func (cfg Config) Check(filename string, size int64) error {
+	kind, ok := KindOf(filename)
+	if !ok {
+		return ErrUnsupported
+	}
+	if !cfg.enabled(kind) {
+		return ErrDisabled
+	}
+	if cfg.MaxBytes > 0 && size > cfg.MaxBytes {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+func (cfg Config) enabled(kind Kind) bool {
+	switch kind {
+	case KindPDF:
+		return cfg.EnablePDF
+	case KindXLSX:
+		return cfg.EnableXLSX
+	case KindDOCX:
+		return cfg.EnableDOCX
+	}
+	return false
+}
+
+// ExtractText は添付ファイルの内容からテキストを抽出し、文字数上限で切り詰めて返します。
+func (cfg Config) ExtractText(filename string, data []byte) (string, error) {
+	if err := cfg.Check(filename, int64(len(data))); err != nil {
+		return "", err
+	}
+
+	kind, _ := KindOf(filename)
+
+	var text string
+	var err error
+	switch kind {
+	case KindPDF:
+		text, err = extractPDF(data, cfg.extractLimit())
+	case KindXLSX:
+		text, err = extractXLSX(data, cfg.extractLimit())
+	case KindDOCX:
+		text, err = extractDOCX(data, cfg.extractLimit())
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return truncate(normalizeSpace(text), cfg.MaxTextChars), nil
+}
+
+// normalizeSpace は連続する空行や行末の空白を除去します。
+func normalizeSpace(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t　")
+		if line == "" {
+			if blank {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+func truncate(text string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:max])
+}
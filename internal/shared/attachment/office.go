@@ -0,0 +1,299 @@
+package attachment
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// extractLimit は抽出時の展開サイズ・テキストの上限です。圧縮率の高いファイル（ZIP爆弾など）でメモリを使い切らないようにします。
+type extractLimit struct {
+	// 展開後のZIPエントリ（docx/xlsx）のサイズ上限（0以下は無制限）
+	maxEntryBytes int64
+	// 抽出するテキストのバイト数上限（0以下は無制限）
+	maxTextBytes int
+}
+
+func (cfg Config) extractLimit() extractLimit {
+	l := extractLimit{maxEntryBytes: cfg.MaxBytes}
+	if cfg.MaxTextChars > 0 {
+		// 文字数上限で切り詰める前のテキストなので、1文字の最大バイト数で見積もる
+		l.maxTextBytes = cfg.MaxTextChars * utf8.UTFMax
+	}
+	return l
+}
+
+// open はエントリを展開サイズの上限付きで開きます。ヘッダーのサイズが上限を超える場合は ErrTooLarge を返します。
+func (l extractLimit) open(f *zip.File) (io.ReadCloser, error) {
+	if l.maxEntryBytes <= 0 {
+		return f.Open()
+	}
+	if f.UncompressedSize64 > uint64(l.maxEntryBytes) {
+		return nil, fmt.Errorf("%s: %w", f.Name, ErrTooLarge)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	// ヘッダーのサイズが偽装されていても上限を超えて展開しない
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(rc, l.maxEntryBytes), rc}, nil
+}
+
+// full は抽出したテキストが上限に達したかを判定します。
+func (l extractLimit) full(n int) bool {
+	return l.maxTextBytes > 0 && n >= l.maxTextBytes
+}
+
+// extractDOCX はWord文書（word/document.xml）の本文を段落ごとに改行して抽出します。
+func extractDOCX(data []byte, limit extractLimit) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("docx: %w", err)
+	}
+
+	f := findZipFile(zr, "word/document.xml")
+	if f == nil {
+		return "", fmt.Errorf("docx: word/document.xml not found")
+	}
+
+	rc, err := limit.open(f)
+	if err != nil {
+		return "", fmt.Errorf("docx: %w", err)
+	}
+	defer rc.Close()
+
+	var sb strings.Builder
+	dec := xml.NewDecoder(rc)
+	inText := false
+	for !limit.full(sb.Len()) {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("docx: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteString("\t")
+			case "br", "cr":
+				sb.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteString("\n")
+			case "tc":
+				// 表のセル区切り
+				sb.WriteString("\t")
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// extractXLSX はExcelブックの全シートを、セルをタブ・行を改行で区切って抽出します。
+func extractXLSX(data []byte, limit extractLimit) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("xlsx: %w", err)
+	}
+
+	shared, err := readSharedStrings(zr, limit)
+	if err != nil {
+		return "", err
+	}
+
+	var sheets []*zip.File
+	for _, f := range zr.File {
+		if path.Dir(f.Name) == "xl/worksheets" && strings.HasSuffix(f.Name, ".xml") {
+			sheets = append(sheets, f)
+		}
+	}
+	// sheet1.xml, sheet2.xml ... の順に並べる
+	sort.Slice(sheets, func(i, j int) bool {
+		return sheetNumber(sheets[i].Name) < sheetNumber(sheets[j].Name)
+	})
+
+	var sb strings.Builder
+	for _, f := range sheets {
+		if limit.full(sb.Len()) {
+			break
+		}
+		text, err := readSheet(f, shared, limit)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		sb.WriteString(text)
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+func readSharedStrings(zr *zip.Reader, limit extractLimit) ([]string, error) {
+	f := findZipFile(zr, "xl/sharedStrings.xml")
+	if f == nil {
+		return nil, nil
+	}
+	rc, err := limit.open(f)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %w", err)
+	}
+	defer rc.Close()
+
+	var shared []string
+	var sb strings.Builder
+	// 共有文字列の合計サイズ（上限を超えた分は読み込まない）
+	total := 0
+	dec := xml.NewDecoder(rc)
+	inText := false
+	for !limit.full(total + sb.Len()) {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("xlsx: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				sb.Reset()
+			case "t":
+				inText = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				shared = append(shared, sb.String())
+				total += sb.Len()
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return shared, nil
+}
+
+func readSheet(f *zip.File, shared []string, limit extractLimit) (string, error) {
+	rc, err := limit.open(f)
+	if err != nil {
+		return "", fmt.Errorf("xlsx: %w", err)
+	}
+	defer rc.Close()
+
+	var sb strings.Builder
+	var row []string
+	// 行に追加済みのセルの合計サイズ
+	rowBytes := 0
+	var cellType string
+	var value strings.Builder
+	inValue := false
+
+	dec := xml.NewDecoder(rc)
+	for !limit.full(sb.Len() + rowBytes + value.Len()) {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("xlsx: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row = row[:0]
+				rowBytes = 0
+			case "c":
+				cellType = ""
+				value.Reset()
+				for _, a := range t.Attr {
+					if a.Name.Local == "t" {
+						cellType = a.Value
+					}
+				}
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				v := value.String()
+				if cellType == "s" {
+					if idx, err := strconv.Atoi(v); err == nil && idx >= 0 && idx < len(shared) {
+						v = shared[idx]
+					} else {
+						// 上限を超えて読み込まなかった共有文字列は番号を出力しない
+						v = ""
+					}
+				}
+				if strings.TrimSpace(v) != "" {
+					row = append(row, v)
+					rowBytes += len(v)
+				}
+			case "row":
+				if len(row) > 0 {
+					sb.WriteString(strings.Join(row, "\t"))
+					sb.WriteString("\n")
+				}
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+func findZipFile(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func sheetNumber(name string) int {
+	base := strings.TrimSuffix(path.Base(name), ".xml")
+	n, err := strconv.Atoi(strings.TrimPrefix(base, "sheet"))
+	if err != nil {
+		return 1 << 30
+	}
+	return n
+}
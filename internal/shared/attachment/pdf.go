@@ -0,0 +1,39 @@
+package attachment
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// extractPDF はPDFのテキストレイヤーを抽出します（画像のみのPDFは空文字となります）。
+func extractPDF(data []byte, limit extractLimit) (text string, err error) {
+	// 破損したPDFでpanicする場合があるため、エラーとして扱う
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pdf: %v", r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("pdf: %w", err)
+	}
+
+	plain, err := r.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("pdf: %w", err)
+	}
+
+	// 展開後のテキストが上限を超えて読み込まれないようにする
+	if limit.maxTextBytes > 0 {
+		plain = io.LimitReader(plain, int64(limit.maxTextBytes))
+	}
+	b, err := io.ReadAll(plain)
+	if err != nil {
+		return "", fmt.Errorf("pdf: %w", err)
+	}
+	return string(b), nil
+}
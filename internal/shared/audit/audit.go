@@ -0,0 +1,178 @@
+// Package audit は要員・案件などのレコードに対する変更履歴（誰が・どの経路で・どの項目をどう変えたか）を記録します。
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound は指定した履歴が存在しない場合のエラーです。
+var ErrNotFound = errors.New("audit log not found")
+
+// Actor は操作を行ったユーザーと値の出所です。
+type Actor struct {
+	UserID  *uuid.UUID
+	Source  Source
+	BatchID *uint
+}
+
+// Manual は画面からの手動操作を表す Actor を返す
+func Manual(userID uuid.UUID) Actor {
+	return Actor{UserID: &userID, Source: SourceManual}
+}
+
+// Extractor は抽出バッチによる操作を表す Actor を返す
+func Extractor(userID uuid.UUID, batchID uint) Actor {
+	return Actor{UserID: &userID, Source: SourceExtractor, BatchID: &batchID}
+}
+
+// Change は1項目の変更前後の値（JSON）です。
+type Change struct {
+	Field  string
+	Before json.RawMessage
+	After  json.RawMessage
+}
+
+// Diff は before と after をJSONに変換して fields の各項目を比較し、値が変わった項目を返す
+func Diff(before, after any, fields []string) ([]Change, error) {
+	b, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, f := range fields {
+		if bytes.Equal(normalize(b[f]), normalize(a[f])) {
+			continue
+		}
+		changes = append(changes, Change{Field: f, Before: b[f], After: a[f]})
+	}
+	return changes, nil
+}
+
+// RecordCreate は作成時の値を記録する
+func RecordCreate(db *gorm.DB, resourceType, resourceID string, actor Actor, snapshot any) error {
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+	return db.Create(newLog(resourceType, resourceID, ActionCreate, actor, "", nil, raw)).Error
+}
+
+// RecordCreates は複数レコードの作成時の値をまとめて記録する
+func RecordCreates[T any](db *gorm.DB, resourceType string, records []T, resourceID func(*T) string, actor Actor) error {
+	if len(records) == 0 {
+		return nil
+	}
+	logs := make([]*Log, 0, len(records))
+	for i := range records {
+		raw, err := json.Marshal(records[i])
+		if err != nil {
+			return fmt.Errorf("marshal snapshot: %w", err)
+		}
+		logs = append(logs, newLog(resourceType, resourceID(&records[i]), ActionCreate, actor, "", nil, raw))
+	}
+	return db.Create(&logs).Error
+}
+
+// RecordChanges は項目ごとの変更を記録する
+func RecordChanges(db *gorm.DB, resourceType, resourceID string, action Action, actor Actor, changes []Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	logs := make([]*Log, 0, len(changes))
+	for _, ch := range changes {
+		logs = append(logs, newLog(resourceType, resourceID, action, actor, ch.Field, ch.Before, ch.After))
+	}
+	return db.Create(&logs).Error
+}
+
+// RecordAction は削除・復元など項目を伴わない操作を記録する
+func RecordAction(db *gorm.DB, resourceType, resourceID string, action Action, actor Actor) error {
+	return db.Create(newLog(resourceType, resourceID, action, actor, "", nil, nil)).Error
+}
+
+// History はレコードの変更履歴を新しい順に返す
+func History(db *gorm.DB, resourceType, resourceID string) ([]Log, error) {
+	var logs []Log
+	err := db.Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).
+		Order("created_at DESC, id DESC").
+		Find(&logs).Error
+	return logs, err
+}
+
+// FindFieldChange は差し戻しの対象となる項目単位の履歴を取得する
+func FindFieldChange(db *gorm.DB, resourceType, resourceID string, logID uint) (*Log, error) {
+	var l Log
+	err := db.Where("resource_type = ? AND resource_id = ? AND field <> ''", resourceType, resourceID).
+		First(&l, logID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// RevertBody は履歴の変更前の値を、PATCHと同じ形式のJSON（{"項目": 値}）にして返す
+func (l *Log) RevertBody() ([]byte, error) {
+	value := json.RawMessage(l.Before)
+	if len(value) == 0 {
+		value = json.RawMessage("null")
+	}
+	return json.Marshal(map[string]json.RawMessage{l.Field: value})
+}
+
+func newLog(resourceType, resourceID string, action Action, actor Actor, field string, before, after json.RawMessage) *Log {
+	return &Log{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Action:       action,
+		Field:        field,
+		Before:       nullable(before),
+		After:        nullable(after),
+		ActorID:      actor.UserID,
+		Source:       actor.Source,
+		BatchID:      actor.BatchID,
+	}
+}
+
+// toFields は構造体をJSONキーごとの値に分解する
+func toFields(v any) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	return fields, nil
+}
+
+// normalize は未設定（omitempty で省略）・null・空配列を同じ値として比較できるようにする
+func normalize(v json.RawMessage) []byte {
+	if slices.Contains([]string{"", "null", "[]"}, string(v)) {
+		return nil
+	}
+	return v
+}
+
+// nullable は値が無い場合にNULLとして保存する
+func nullable(v json.RawMessage) []byte {
+	if len(v) == 0 || string(v) == "null" {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// 監査対象のリソース種別
+const (
+	ResourceHumanResource = "human_resource"
+	ResourceProject       = "project"
+)
+
+// 操作の種別
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+	ActionRevert  Action = "revert"
+)
+
+// 値の出所（AIによる抽出か、人による入力か）
+type Source string
+
+const (
+	SourceExtractor Source = "extractor"
+	SourceManual    Source = "manual"
+)
+
+// Log はレコードに対する操作の履歴です。
+// 更新・差し戻しは項目ごとに1行、作成は Field を空にして作成時の値全体を After に保存する。
+type Log struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	ResourceType string         `gorm:"type:varchar(32);not null;index:idx_audit_resource,priority:1" json:"resource_type"`
+	ResourceID   string         `gorm:"type:varchar(64);not null;index:idx_audit_resource,priority:2" json:"resource_id"`
+	Action       Action         `gorm:"type:varchar(16);not null" json:"action"`
+	Field        string         `gorm:"type:varchar(64)" json:"field,omitempty"`
+	Before       datatypes.JSON `gorm:"type:json" json:"before,omitempty"`
+	After        datatypes.JSON `gorm:"type:json" json:"after,omitempty"`
+	ActorID      *uuid.UUID     `gorm:"type:char(36);index" json:"actor_id,omitempty"`
+	Source       Source         `gorm:"type:varchar(16);not null" json:"source"`
+	BatchID      *uint          `gorm:"index" json:"batch_id,omitempty"`
+	CreatedAt    time.Time      `gorm:"index:idx_audit_resource,priority:3" json:"created_at"`
+}
+
+func (Log) TableName() string {
+	return "audit_logs"
+}
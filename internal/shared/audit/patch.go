@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"shakehandz-api/internal/shared/apierror"
+	"shakehandz-api/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Patch は手動での部分更新（PATCH・差し戻し）の対象となるリソースです。
+type Patch struct {
+	ResourceType string
+	ResourceID   string
+	// 編集できる項目（JSONキーとカラム名は同一）
+	Editable map[string]bool
+	// 項目を反映した後のレコードを検証する
+	Validate func() error
+}
+
+// ApplyPatch は body の項目を record（モデルのポインタ）に反映して保存し、項目ごとの変更履歴を記録する
+// record の変更者は呼び出し側で設定しておくこと（updated_by_id・updated_at も合わせて更新する）
+// 失敗した場合はエラーレスポンスを送信して false を返す
+func ApplyPatch(c *gin.Context, db *gorm.DB, record any, body []byte, p Patch, action Action, actor Actor) bool {
+	resource := errorResource(p.ResourceType)
+
+	// JSONの配列は既存のスライスを再利用してデコードされるため、変更前の値は先にJSONで退避する
+	before, err := json.Marshal(record)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unknown, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: resource,
+		})
+		return false
+	}
+
+	columns, ok := BindEditable(c, body, record, p.ResourceType, p.Editable)
+	if !ok {
+		return false
+	}
+	if len(columns) == 0 {
+		response.SendError(c, apierror.Common.BadRequest, response.ErrorDetail{
+			Detail:   "no fields to update",
+			Resource: resource,
+		})
+		return false
+	}
+
+	if err := p.Validate(); err != nil {
+		response.SendError(c, apierror.Common.ValidationFailed, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: resource,
+		})
+		return false
+	}
+
+	changes, err := Diff(json.RawMessage(before), record, columns)
+	if err != nil {
+		response.SendError(c, apierror.Common.Unknown, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: resource,
+		})
+		return false
+	}
+
+	// 指定された項目のみ更新する（nullを指定した項目はNULLに戻す）
+	columns = append(columns, "updated_by_id", "updated_at")
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(record).Select(columns).Updates(record).Error; err != nil {
+			return err
+		}
+		return RecordChanges(tx, p.ResourceType, p.ResourceID, action, actor, changes)
+	})
+	if err != nil {
+		response.SendError(c, apierror.Common.DatabaseError, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: resource,
+		})
+		return false
+	}
+	return true
+}
+
+// BindEditable はJSONのうち編集可能な項目のみを record に反映し、反映したカラム名を返す
+// 編集できない項目が含まれる場合はエラーレスポンスを送信して ok=false を返す
+func BindEditable(c *gin.Context, body []byte, record any, resourceType string, editable map[string]bool) ([]string, bool) {
+	resource := errorResource(resourceType)
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		response.SendError(c, apierror.Common.JSONParseFailed, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: resource,
+		})
+		return nil, false
+	}
+
+	columns := make([]string, 0, len(fields))
+	for name := range fields {
+		if !editable[name] {
+			response.SendError(c, apierror.Common.ValidationFailed, response.ErrorDetail{
+				Detail:   "field cannot be edited",
+				Resource: resource,
+				Field:    name,
+			})
+			return nil, false
+		}
+		columns = append(columns, name)
+	}
+	slices.Sort(columns)
+
+	if err := json.Unmarshal(body, record); err != nil {
+		response.SendError(c, apierror.Common.JSONParseFailed, response.ErrorDetail{
+			Detail:   err.Error(),
+			Resource: resource,
+		})
+		return nil, false
+	}
+	return columns, true
+}
+
+// errorResource はエラーレスポンスのリソース名（例: human_resource → human resource）
+func errorResource(resourceType string) string {
+	return strings.ReplaceAll(resourceType, "_", " ")
+}
@@ -0,0 +1,132 @@
+package cache_extractor
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 購読者1件あたりのバッファ。溢れたイベントは破棄する
+const eventBufferSize = 64
+
+// Event は抽出処理の進捗イベントです。
+type Event struct {
+	Type          string     `json:"type"`
+	BatchID       uint       `json:"batch_id,omitempty"`
+	ExtractorType string     `json:"extractor_type"`
+	Message       string     `json:"message,omitempty"`
+	Count         int        `json:"count,omitempty"`
+	Status        *JobStatus `json:"status,omitempty"` // イベント時点の進捗
+	Time          time.Time  `json:"time"`
+}
+
+// EventBroker は進捗イベントの配信先です。チャネルはStatusIDと同じ単位（ユーザー・抽出種別）です。
+type EventBroker interface {
+	Publish(ctx context.Context, channel string, ev Event) error
+	// Subscribe はイベントを受信するチャネルと、購読を終了する関数を返します。
+	Subscribe(ctx context.Context, channel string) (<-chan Event, func(), error)
+}
+
+// NewEventBroker はRedisが設定されていればRedis Pub/Sub、なければプロセス内で配信するブローカーを返します。
+func NewEventBroker(rdb *redis.Client) EventBroker {
+	if rdb != nil {
+		return &RedisEventBroker{RDB: rdb}
+	}
+	return NewMemoryEventBroker()
+}
+
+func eventChannel(channel string) string {
+	return "job:events:" + channel
+}
+
+// RedisEventBroker はRedis Pub/Subでイベントを配信します。
+type RedisEventBroker struct {
+	RDB *redis.Client
+}
+
+func (r *RedisEventBroker) Publish(ctx context.Context, channel string, ev Event) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return r.RDB.Publish(ctx, eventChannel(channel), b).Err()
+}
+
+func (r *RedisEventBroker) Subscribe(ctx context.Context, channel string) (<-chan Event, func(), error) {
+	pubsub := r.RDB.Subscribe(ctx, eventChannel(channel))
+	// 購読の確立を待つ
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, nil, err
+	}
+
+	out := make(chan Event, eventBufferSize)
+	go func() {
+		defer close(out)
+		for m := range pubsub.Channel() {
+			var ev Event
+			if err := json.Unmarshal([]byte(m.Payload), &ev); err != nil {
+				log.Printf("ERROR: イベントの解析に失敗: %v", err)
+				continue
+			}
+			select {
+			case out <- ev:
+			default:
+			}
+		}
+	}()
+
+	return out, func() { pubsub.Close() }, nil
+}
+
+// MemoryEventBroker はプロセス内の購読者にイベントを配信します。
+type MemoryEventBroker struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan Event]struct{}
+}
+
+func NewMemoryEventBroker() *MemoryEventBroker {
+	return &MemoryEventBroker{subs: make(map[string]map[chan Event]struct{})}
+}
+
+func (m *MemoryEventBroker) Publish(ctx context.Context, channel string, ev Event) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for ch := range m.subs[channel] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+	return nil
+}
+
+func (m *MemoryEventBroker) Subscribe(ctx context.Context, channel string) (<-chan Event, func(), error) {
+	ch := make(chan Event, eventBufferSize)
+
+	m.mu.Lock()
+	if m.subs[channel] == nil {
+		m.subs[channel] = make(map[chan Event]struct{})
+	}
+	m.subs[channel][ch] = struct{}{}
+	m.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			m.mu.Lock()
+			delete(m.subs[channel], ch)
+			if len(m.subs[channel]) == 0 {
+				delete(m.subs, channel)
+			}
+			m.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, unsubscribe, nil
+}
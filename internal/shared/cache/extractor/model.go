@@ -10,4 +10,17 @@ type JobStatus struct {
 	Status    string    `json:"status"`
 	Message   string    `json:"message"`
 	StartedAt time.Time `json:"started_at,omitempty"`
+
+	// 実行中（または直近）のバッチ情報
+	BatchID       uint   `json:"batch_id,omitempty"`
+	ExtractorType string `json:"extractor_type,omitempty"`
+
+	// 進捗
+	MessagesFetched int    `json:"messages_fetched"`
+	ChunksSent      int    `json:"chunks_sent"`
+	RecordsSaved    int    `json:"records_saved"`
+	RecordsRejected int    `json:"records_rejected"`
+	LastError       string `json:"last_error,omitempty"`
+
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
 }
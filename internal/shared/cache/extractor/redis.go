@@ -15,7 +15,7 @@ func UpdateStatusInRedis(ctx context.Context, rdb *redis.Client, status *JobStat
 	key := fmt.Sprintf("job:status:%s", status.ID)
 	// Redisからステータスを取得
 	oldStatus, err := FetchJobStatus(ctx, rdb, status.ID)
-	if err != nil {
+	if err != nil && err != redis.Nil {
 		log.Printf("ERROR: Failed to fetch redis: %v", err)
 	}
 
@@ -33,8 +33,7 @@ func UpdateStatusInRedis(ctx context.Context, rdb *redis.Client, status *JobStat
 		return fmt.Errorf("failed to marshal job status: %w", err)
 	}
 
-	expiration := 5 * time.Minute
-	if err := rdb.Set(ctx, key, newStatusJSON, expiration).Err(); err != nil {
+	if err := rdb.Set(ctx, key, newStatusJSON, StatusTTL).Err(); err != nil {
 		return fmt.Errorf("failed to set job status in redis: %w", err)
 	}
 
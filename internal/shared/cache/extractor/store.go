@@ -0,0 +1,89 @@
+package cache_extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ステータスを保持する期間
+const StatusTTL = 24 * time.Hour
+
+// ErrStatusNotFound はステータスが存在しない場合のエラーです。
+var ErrStatusNotFound = errors.New("job status not found")
+
+// StatusStore は抽出ジョブのステータスの保存先です。
+type StatusStore interface {
+	Get(ctx context.Context, id string) (*JobStatus, error)
+	Set(ctx context.Context, status *JobStatus) error
+}
+
+// StatusID はユーザー・抽出種別ごとのステータスのIDを返します。
+func StatusID(userID uuid.UUID, extractorType string) string {
+	return fmt.Sprintf("%s:%s", userID, extractorType)
+}
+
+// NewStatusStore はRedisが設定されていればRedis、なければメモリにステータスを保存するストアを返します。
+// メモリの場合、ワーカーを別プロセス（cmd/worker）で動かすとAPIサーバーからは参照できません。
+func NewStatusStore(rdb *redis.Client) StatusStore {
+	if rdb != nil {
+		return &RedisStatusStore{RDB: rdb}
+	}
+	return NewMemoryStatusStore()
+}
+
+// RedisStatusStore はRedisにステータスを保存します。
+type RedisStatusStore struct {
+	RDB *redis.Client
+}
+
+func (r *RedisStatusStore) Get(ctx context.Context, id string) (*JobStatus, error) {
+	status, err := FetchJobStatus(ctx, r.RDB, id)
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrStatusNotFound
+	}
+	return status, err
+}
+
+func (r *RedisStatusStore) Set(ctx context.Context, status *JobStatus) error {
+	return UpdateStatusInRedis(ctx, r.RDB, status)
+}
+
+// MemoryStatusStore はプロセス内のメモリにステータスを保存します。
+type MemoryStatusStore struct {
+	mu       sync.RWMutex
+	statuses map[string]JobStatus
+}
+
+func NewMemoryStatusStore() *MemoryStatusStore {
+	return &MemoryStatusStore{statuses: make(map[string]JobStatus)}
+}
+
+func (m *MemoryStatusStore) Get(ctx context.Context, id string) (*JobStatus, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status, ok := m.statuses[id]
+	if !ok || time.Since(status.UpdatedAt) > StatusTTL {
+		return nil, ErrStatusNotFound
+	}
+	return &status, nil
+}
+
+func (m *MemoryStatusStore) Set(ctx context.Context, status *JobStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.statuses[status.ID]; ok {
+		status.CreatedAt = old.CreatedAt
+	} else {
+		status.CreatedAt = time.Now()
+	}
+	m.statuses[status.ID] = *status
+	return nil
+}
@@ -1,87 +1,220 @@
 package crypto
 
 import (
+	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
-	"os"
-	"strings"
 )
 
-func getKey() ([]byte, error) {
-	kb64 := strings.TrimSpace(os.Getenv("GOOGLE_TOKEN_ENC_KEY_BASE64"))
-	if kb64 == "" {
-		return nil, errors.New("GOOGLE_TOKEN_ENC_KEY_BASE64 not set")
+// 暗号文の形式
+//
+//	v1:       magic(4) | keyIDLen(1) | keyID | nonce | ciphertext  （keyIDは認証付きデータとして検証）
+//	envelope: envelope.go 参照（TOKEN_ENCRYPTION_MODE=envelope）
+//	legacy:   nonce | ciphertext                                    （GOOGLE_TOKEN_ENC_KEY_BASE64 で暗号化）
+var magicV1 = []byte("SHK\x01")
+
+// ErrUnknownKeyID は暗号文の鍵IDに対応する鍵が設定されていない場合のエラーです。
+var ErrUnknownKeyID = errors.New("crypto: unknown key id")
+
+// EncryptToBytes は TOKEN_ENCRYPTION_MODE の形式で暗号化します。
+func EncryptToBytes(plain string) ([]byte, error) {
+	return EncryptToBytesContext(context.Background(), plain)
+}
+
+// EncryptToBytesContext は TOKEN_ENCRYPTION_MODE の形式で暗号化します。
+// keyring: 有効な鍵（GOOGLE_TOKEN_ENC_ACTIVE_KEY_ID）で暗号化し、鍵IDを付与する
+// envelope: レコードごとのデータキーで暗号化し、KeyManager でラップしたデータキーを付与する
+func EncryptToBytesContext(ctx context.Context, plain string) ([]byte, error) {
+	mode, err := encryptionMode()
+	if err != nil {
+		return nil, err
+	}
+	if mode == ModeEnvelope {
+		km, err := keyManager()
+		if err != nil {
+			return nil, err
+		}
+		return encryptEnvelope(ctx, km, []byte(plain))
+	}
+
+	ring, err := loadKeyring()
+	if err != nil {
+		return nil, fmt.Errorf("loadKeyring: %w", err)
+	}
+	return encryptWithKey(ring.activeID, ring.keys[ring.activeID], []byte(plain))
+}
+
+// DecryptFromBytes は暗号文の形式を判定して復号します。鍵IDの無い旧形式にも対応します。
+func DecryptFromBytes(raw []byte) (string, error) {
+	return DecryptFromBytesContext(context.Background(), raw)
+}
+
+// DecryptFromBytesContext は暗号文の形式を判定して復号します。鍵IDの無い旧形式にも対応します。
+func DecryptFromBytesContext(ctx context.Context, raw []byte) (string, error) {
+	if bytes.HasPrefix(raw, magicEnvelope) {
+		return decryptEnvelope(ctx, raw)
+	}
+
+	ring, err := loadKeyring()
+	if err != nil {
+		return "", fmt.Errorf("loadKeyring: %w", err)
+	}
+
+	keyID, body, versioned, err := parseCiphertext(raw)
+	if err != nil {
+		return "", err
+	}
+	if !versioned {
+		return decryptLegacy(ring, raw)
+	}
+
+	key, ok := ring.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKeyID, keyID)
+	}
+	pt, err := open(key, body, []byte(keyID))
+	if err != nil {
+		return "", err
 	}
-	// 改行・空白を除去（コピペ対策）
-	kb64 = strings.ReplaceAll(kb64, "\n", "")
-	kb64 = strings.ReplaceAll(kb64, "\r", "")
-	kb64 = strings.ReplaceAll(kb64, " ", "")
+	return string(pt), nil
+}
 
-	// まず標準Base64
-	key, err := base64.StdEncoding.DecodeString(kb64)
+// KeyIDOf は暗号文の鍵ID（エンベロープ形式の場合はKEKのID）を返します。旧形式の場合は versioned=false を返します。
+func KeyIDOf(raw []byte) (keyID string, versioned bool) {
+	if bytes.HasPrefix(raw, magicEnvelope) {
+		keyID, _, _, _, err := parseEnvelope(raw)
+		return keyID, err == nil
+	}
+	keyID, _, versioned, err := parseCiphertext(raw)
 	if err != nil {
-		// URL-safe やパディング無しの可能性に対応
-		if k2, err2 := base64.RawStdEncoding.DecodeString(kb64); err2 == nil {
-			key = k2
-		} else if k3, err3 := base64.RawURLEncoding.DecodeString(kb64); err3 == nil {
-			key = k3
-		} else {
-			return nil, fmt.Errorf("decode base64 failed: %w", err)
+		return "", false
+	}
+	return keyID, versioned
+}
+
+// ActiveKeyID は暗号化に使用する鍵ID（エンベロープ形式の場合はKEKのID）を返します。
+func ActiveKeyID() (string, error) {
+	mode, err := encryptionMode()
+	if err != nil {
+		return "", err
+	}
+	if mode == ModeEnvelope {
+		km, err := keyManager()
+		if err != nil {
+			return "", err
 		}
+		return km.ActiveKeyID(), nil
 	}
-	// 長さ検証（AES: 16/24/32）
-	if n := len(key); n != 16 && n != 24 && n != 32 {
-		return nil, fmt.Errorf("invalid key length: %d (must be 16/24/32 bytes)", n)
+
+	ring, err := loadKeyring()
+	if err != nil {
+		return "", err
 	}
-	return key, nil
+	return ring.activeID, nil
 }
 
-func EncryptToBytes(plain string) ([]byte, error) {
-	key, err := getKey()
+// NeedsReencrypt は暗号文が現在の暗号化モード・有効な鍵以外（旧形式を含む）で暗号化されているかを返します。
+func NeedsReencrypt(raw []byte) (bool, error) {
+	mode, err := encryptionMode()
 	if err != nil {
-		return nil, fmt.Errorf("getKey: %w", err)
+		return false, err
 	}
-	block, err := aes.NewCipher(key)
+	active, err := ActiveKeyID()
 	if err != nil {
-		return nil, fmt.Errorf("newCipher: %w", err)
+		return false, err
 	}
-	gcm, err := cipher.NewGCM(block)
+	if isEnvelope := bytes.HasPrefix(raw, magicEnvelope); isEnvelope != (mode == ModeEnvelope) {
+		return true, nil
+	}
+	keyID, versioned := KeyIDOf(raw)
+	return !versioned || keyID != active, nil
+}
+
+func encryptWithKey(keyID string, key, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return nil, fmt.Errorf("newGCM: %w", err)
+		return nil, err
 	}
 	nonce := make([]byte, gcm.NonceSize())
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return nil, fmt.Errorf("nonce: %w", err)
 	}
-	out := gcm.Seal(nonce, nonce, []byte(plain), nil)
-	return out, nil
+
+	out := make([]byte, 0, len(magicV1)+1+len(keyID)+len(nonce)+len(plain)+gcm.Overhead())
+	out = append(out, magicV1...)
+	out = append(out, byte(len(keyID)))
+	out = append(out, keyID...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plain, []byte(keyID)), nil
 }
 
-func DecryptFromBytes(raw []byte) (string, error) {
-	key, err := getKey()
-	if err != nil {
-		return "", fmt.Errorf("getKey: %w", err)
+// parseCiphertext は暗号文をヘッダーと本体（nonce|ciphertext）に分割する
+func parseCiphertext(raw []byte) (keyID string, body []byte, versioned bool, err error) {
+	if !bytes.HasPrefix(raw, magicV1) {
+		return "", raw, false, nil
 	}
-	block, err := aes.NewCipher(key)
+	rest := raw[len(magicV1):]
+	if len(rest) < 1 {
+		return "", nil, true, errors.New("ciphertext too short")
+	}
+	n := int(rest[0])
+	if n == 0 || len(rest) < 1+n {
+		return "", nil, true, errors.New("invalid key id header")
+	}
+	return string(rest[1 : 1+n]), rest[1+n:], true, nil
+}
+
+// decryptLegacy は鍵IDの無い旧形式を復号する
+// 旧鍵（GOOGLE_TOKEN_ENC_KEY_BASE64）を優先し、無い場合は登録済みの全ての鍵を試す
+func decryptLegacy(ring *keyring, raw []byte) (string, error) {
+	if key, ok := ring.keys[LegacyKeyID]; ok {
+		pt, err := open(key, raw, nil)
+		if err != nil {
+			return "", err
+		}
+		return string(pt), nil
+	}
+
+	var lastErr error = ErrUnknownKeyID
+	for _, id := range ring.order {
+		pt, err := open(ring.keys[id], raw, nil)
+		if err == nil {
+			return string(pt), nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+func open(key, body, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return "", fmt.Errorf("newCipher: %w", err)
+		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
+	if len(body) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ct := body[:gcm.NonceSize()], body[gcm.NonceSize():]
+	pt, err := gcm.Open(nil, nonce, ct, aad)
 	if err != nil {
-		return "", fmt.Errorf("newGCM: %w", err)
+		return nil, fmt.Errorf("open: %w", err)
 	}
-	if len(raw) < gcm.NonceSize() {
-		return "", errors.New("ciphertext too short")
+	return pt, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("newCipher: %w", err)
 	}
-	nonce, ct := raw[:gcm.NonceSize()], raw[gcm.NonceSize():]
-	pt, err := gcm.Open(nil, nonce, ct, nil)
+	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return "", fmt.Errorf("open: %w", err)
+		return nil, fmt.Errorf("newGCM: %w", err)
 	}
-	return string(pt), nil
+	return gcm, nil
 }
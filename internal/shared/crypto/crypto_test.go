@@ -0,0 +1,180 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+// testKey は長さ n の鍵を fill で埋めて返す
+func testKey(fill byte, n int) []byte {
+	return bytes.Repeat([]byte{fill}, n)
+}
+
+func b64(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// setKeyringEnv は鍵リングの環境変数を設定する（空文字は未設定として扱われる）
+func setKeyringEnv(t *testing.T, legacy, keys, active string) {
+	t.Helper()
+	t.Setenv("TOKEN_ENCRYPTION_MODE", ModeKeyring)
+	t.Setenv("GOOGLE_TOKEN_ENC_KEY_BASE64", legacy)
+	t.Setenv("GOOGLE_TOKEN_ENC_KEYS", keys)
+	t.Setenv("GOOGLE_TOKEN_ENC_ACTIVE_KEY_ID", active)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	k1, k2 := b64(testKey(1, 32)), b64(testKey(2, 16))
+
+	tests := []struct {
+		name       string
+		legacy     string
+		keys       string
+		active     string
+		wantActive string
+		wantErr    bool
+	}{
+		{name: "旧鍵のみ", legacy: k1, wantActive: LegacyKeyID},
+		{name: "最後の鍵が有効", keys: "k1:" + k1 + ",k2:" + k2, wantActive: "k2"},
+		{name: "有効な鍵を指定", keys: "k1:" + k1 + ", k2:" + k2, active: "k1", wantActive: "k1"},
+		{name: "旧鍵と併用", legacy: k1, keys: "k2:" + k2, wantActive: "k2"},
+		{name: "未設定", wantErr: true},
+		{name: "未登録の有効な鍵", keys: "k1:" + k1, active: "k9", wantErr: true},
+		{name: "鍵IDの重複", keys: "k1:" + k1 + ",k1:" + k2, wantErr: true},
+		{name: "鍵IDなし", keys: k1, wantErr: true},
+		{name: "不正な鍵ID", keys: "k 1:" + k1, wantErr: true},
+		{name: "不正な鍵長", keys: "k1:" + b64(testKey(1, 10)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setKeyringEnv(t, tt.legacy, tt.keys, tt.active)
+
+			ring, err := loadKeyring()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("loadKeyring() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadKeyring() error = %v", err)
+			}
+			if ring.activeID != tt.wantActive {
+				t.Errorf("activeID = %q, want %q", ring.activeID, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	k1, k2 := b64(testKey(1, 32)), b64(testKey(2, 32))
+	const plain = "refresh-token"
+
+	// 旧形式（鍵IDなし）の暗号文
+	legacyRaw, err := encryptLegacyForTest(testKey(1, 32), plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// k1 で暗号化
+	setKeyringEnv(t, "", "k1:"+k1, "")
+	v1Raw, err := EncryptToBytes(plain)
+	if err != nil {
+		t.Fatalf("EncryptToBytes() error = %v", err)
+	}
+	if id, versioned := KeyIDOf(v1Raw); !versioned || id != "k1" {
+		t.Fatalf("KeyIDOf() = %q, %v, want k1, true", id, versioned)
+	}
+
+	tests := []struct {
+		name          string
+		legacy        string
+		keys          string
+		raw           []byte
+		wantErr       error
+		wantReencrypt bool
+	}{
+		{name: "有効な鍵で暗号化済み", keys: "k1:" + k1, raw: v1Raw},
+		{name: "ローテーション後も旧鍵で復号できる", keys: "k1:" + k1 + ",k2:" + k2, raw: v1Raw, wantReencrypt: true},
+		{name: "旧鍵を削除すると復号できない", keys: "k2:" + k2, raw: v1Raw, wantErr: ErrUnknownKeyID, wantReencrypt: true},
+		{name: "旧形式は旧鍵で復号する", legacy: k1, keys: "k2:" + k2, raw: legacyRaw, wantReencrypt: true},
+		{name: "旧形式は登録済みの鍵を順に試す", keys: "k1:" + k1 + ",k2:" + k2, raw: legacyRaw, wantReencrypt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setKeyringEnv(t, tt.legacy, tt.keys, "")
+
+			got, err := DecryptFromBytes(tt.raw)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("DecryptFromBytes() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || got != plain {
+				t.Fatalf("DecryptFromBytes() = %q, %v, want %q", got, err, plain)
+			}
+
+			needs, err := NeedsReencrypt(tt.raw)
+			if err != nil {
+				t.Fatalf("NeedsReencrypt() error = %v", err)
+			}
+			if needs != tt.wantReencrypt {
+				t.Errorf("NeedsReencrypt() = %v, want %v", needs, tt.wantReencrypt)
+			}
+		})
+	}
+}
+
+func TestDecryptRejectsTamperedKeyID(t *testing.T) {
+	// 同じ鍵を別のIDで登録しても、鍵IDは認証付きデータのため差し替えられない
+	key := b64(testKey(1, 32))
+	setKeyringEnv(t, "", "k1:"+key+",k2:"+key, "k1")
+
+	raw, err := EncryptToBytes("refresh-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Replace(raw, []byte("k1"), []byte("k2"), 1)
+
+	if _, err := DecryptFromBytes(tampered); err == nil {
+		t.Fatal("DecryptFromBytes() error = nil, want authentication error")
+	}
+}
+
+func TestParseCiphertext(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           []byte
+		wantKeyID     string
+		wantVersioned bool
+		wantErr       bool
+	}{
+		{name: "旧形式", raw: []byte("nonce-and-body"), wantVersioned: false},
+		{name: "鍵IDあり", raw: append(append([]byte{}, magicV1...), append([]byte{2}, "k1body"...)...), wantKeyID: "k1", wantVersioned: true},
+		{name: "ヘッダーのみ", raw: magicV1, wantVersioned: true, wantErr: true},
+		{name: "鍵ID長が0", raw: append(append([]byte{}, magicV1...), 0), wantVersioned: true, wantErr: true},
+		{name: "鍵IDが途中で切れている", raw: append(append([]byte{}, magicV1...), 5, 'k'), wantVersioned: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyID, _, versioned, err := parseCiphertext(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCiphertext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if keyID != tt.wantKeyID || versioned != tt.wantVersioned {
+				t.Errorf("parseCiphertext() = %q, %v, want %q, %v", keyID, versioned, tt.wantKeyID, tt.wantVersioned)
+			}
+		})
+	}
+}
+
+// encryptLegacyForTest は鍵IDの無い旧形式（nonce | ciphertext）で暗号化する
+func encryptLegacyForTest(key []byte, plain string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	return gcm.Seal(nonce, nonce, []byte(plain), nil), nil
+}
@@ -0,0 +1,128 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// エンベロープ暗号化の暗号文形式
+//
+//	envelope: magic(4) | keyIDLen(1) | keyID | wrappedLen(2) | wrapped | nonce | ciphertext
+//
+// レコードごとに生成したデータキーで平文を暗号化し、データキーは KeyManager のKEKでラップして同梱する。
+// ヘッダー（magic〜wrapped）は認証付きデータとして検証する。
+var magicEnvelope = []byte("SHK\x02")
+
+// データキーの長さ（AES-256）
+const dataKeySize = 32
+
+// 暗号化モード（TOKEN_ENCRYPTION_MODE）
+const (
+	ModeKeyring  = "keyring"
+	ModeEnvelope = "envelope"
+)
+
+// encryptionMode は新規に暗号化する際の形式を返す（復号は暗号文の形式から判定する）
+func encryptionMode() (string, error) {
+	switch mode := strings.TrimSpace(os.Getenv("TOKEN_ENCRYPTION_MODE")); mode {
+	case "", ModeKeyring:
+		return ModeKeyring, nil
+	case ModeEnvelope:
+		return ModeEnvelope, nil
+	default:
+		return "", fmt.Errorf("TOKEN_ENCRYPTION_MODE: unknown mode %q", mode)
+	}
+}
+
+func encryptEnvelope(ctx context.Context, km KeyManager, plain []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, fmt.Errorf("data key: %w", err)
+	}
+	defer clear(dataKey)
+
+	keyID, wrapped, err := km.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("wrap key: %w", err)
+	}
+	if n := len(keyID); n == 0 || n > 255 {
+		return nil, fmt.Errorf("invalid key id length: %d", n)
+	}
+	if len(wrapped) > 0xFFFF {
+		return nil, fmt.Errorf("wrapped key too long: %d", len(wrapped))
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("nonce: %w", err)
+	}
+
+	header := make([]byte, 0, len(magicEnvelope)+1+len(keyID)+2+len(wrapped))
+	header = append(header, magicEnvelope...)
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+
+	out := append(header, nonce...)
+	return gcm.Seal(out, nonce, plain, header), nil
+}
+
+func decryptEnvelope(ctx context.Context, raw []byte) (string, error) {
+	keyID, wrapped, header, body, err := parseEnvelope(raw)
+	if err != nil {
+		return "", err
+	}
+	km, err := keyManager()
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := km.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("unwrap key: %w", err)
+	}
+	defer clear(dataKey)
+
+	pt, err := open(dataKey, body, header)
+	if err != nil {
+		return "", err
+	}
+	return string(pt), nil
+}
+
+// parseEnvelope はエンベロープ形式の暗号文を分割する
+func parseEnvelope(raw []byte) (keyID string, wrapped, header, body []byte, err error) {
+	if !bytes.HasPrefix(raw, magicEnvelope) {
+		return "", nil, nil, nil, errors.New("not an envelope ciphertext")
+	}
+	p := len(magicEnvelope)
+	if len(raw) < p+1 {
+		return "", nil, nil, nil, errors.New("ciphertext too short")
+	}
+	n := int(raw[p])
+	p++
+	if n == 0 || len(raw) < p+n+2 {
+		return "", nil, nil, nil, errors.New("invalid key id header")
+	}
+	keyID = string(raw[p : p+n])
+	p += n
+	w := int(binary.BigEndian.Uint16(raw[p : p+2]))
+	p += 2
+	if len(raw) < p+w {
+		return "", nil, nil, nil, errors.New("invalid wrapped key header")
+	}
+	wrapped = raw[p : p+w]
+	p += w
+	return keyID, wrapped, raw[:p], raw[p:], nil
+}
@@ -0,0 +1,173 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useLocalKeyManager は鍵ファイルを作成し、エンベロープ暗号化で使用する KeyManager に設定する
+func useLocalKeyManager(t *testing.T, keyFile string) *LocalKeyManager {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(keyFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	km, err := NewLocalKeyManagerFromFile(path)
+	if err != nil {
+		t.Fatalf("NewLocalKeyManagerFromFile() error = %v", err)
+	}
+	SetKeyManager(km)
+	t.Cleanup(func() { SetKeyManager(nil) })
+	return km
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	k1, k2 := b64(testKey(1, 32)), b64(testKey(2, 32))
+	const plain = "refresh-token"
+
+	t.Setenv("TOKEN_ENCRYPTION_MODE", ModeEnvelope)
+	useLocalKeyManager(t, `{"active_key_id":"kek-1","keys":{"kek-1":"`+k1+`"}}`)
+
+	raw, err := EncryptToBytes(plain)
+	if err != nil {
+		t.Fatalf("EncryptToBytes() error = %v", err)
+	}
+	if !bytes.HasPrefix(raw, magicEnvelope) {
+		t.Fatalf("ciphertext is not envelope format: %x", raw[:4])
+	}
+	if id, versioned := KeyIDOf(raw); !versioned || id != "kek-1" {
+		t.Fatalf("KeyIDOf() = %q, %v, want kek-1, true", id, versioned)
+	}
+
+	tests := []struct {
+		name          string
+		mode          string
+		keyFile       string
+		wantErr       error
+		wantReencrypt bool
+	}{
+		{name: "有効なKEKでラップ済み", mode: ModeEnvelope, keyFile: `{"active_key_id":"kek-1","keys":{"kek-1":"` + k1 + `"}}`},
+		{name: "KEKのローテーション後も復号できる", mode: ModeEnvelope, keyFile: `{"active_key_id":"kek-2","keys":{"kek-1":"` + k1 + `","kek-2":"` + k2 + `"}}`, wantReencrypt: true},
+		{name: "旧KEKを削除すると復号できない", mode: ModeEnvelope, keyFile: `{"active_key_id":"kek-2","keys":{"kek-2":"` + k2 + `"}}`, wantErr: ErrUnknownKeyID, wantReencrypt: true},
+		{name: "鍵リング形式に戻すと再暗号化対象", mode: ModeKeyring, keyFile: `{"active_key_id":"kek-1","keys":{"kek-1":"` + k1 + `"}}`, wantReencrypt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setKeyringEnv(t, "", "k1:"+k1, "")
+			t.Setenv("TOKEN_ENCRYPTION_MODE", tt.mode)
+			useLocalKeyManager(t, tt.keyFile)
+
+			// 復号は暗号化モードに関わらず暗号文の形式から判定する
+			got, err := DecryptFromBytes(raw)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("DecryptFromBytes() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || got != plain {
+				t.Fatalf("DecryptFromBytes() = %q, %v, want %q", got, err, plain)
+			}
+
+			needs, err := NeedsReencrypt(raw)
+			if err != nil {
+				t.Fatalf("NeedsReencrypt() error = %v", err)
+			}
+			if needs != tt.wantReencrypt {
+				t.Errorf("NeedsReencrypt() = %v, want %v", needs, tt.wantReencrypt)
+			}
+		})
+	}
+}
+
+func TestKeyringCiphertextNeedsReencryptInEnvelopeMode(t *testing.T) {
+	k1 := b64(testKey(1, 32))
+	setKeyringEnv(t, "", "k1:"+k1, "")
+	raw, err := EncryptToBytes("refresh-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TOKEN_ENCRYPTION_MODE", ModeEnvelope)
+	useLocalKeyManager(t, `{"active_key_id":"k1","keys":{"k1":"`+k1+`"}}`)
+
+	// 鍵IDが同じでも形式が異なるため再暗号化する
+	needs, err := NeedsReencrypt(raw)
+	if err != nil {
+		t.Fatalf("NeedsReencrypt() error = %v", err)
+	}
+	if !needs {
+		t.Error("NeedsReencrypt() = false, want true")
+	}
+	if got, err := DecryptFromBytes(raw); err != nil || got != "refresh-token" {
+		t.Errorf("DecryptFromBytes() = %q, %v", got, err)
+	}
+}
+
+func TestEnvelopeRejectsTamperedHeader(t *testing.T) {
+	km := useLocalKeyManager(t, `{"active_key_id":"kek-1","keys":{"kek-1":"`+b64(testKey(1, 32))+`"}}`)
+
+	raw, err := encryptEnvelope(context.Background(), km, []byte("refresh-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, wrapped, header, _, err := parseEnvelope(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		index int
+	}{
+		{name: "ラップ済みの鍵", index: len(header) - len(wrapped)},
+		{name: "本文", index: len(raw) - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := append([]byte{}, raw...)
+			tampered[tt.index] ^= 0xFF
+			if _, err := decryptEnvelope(context.Background(), tampered); err == nil {
+				t.Fatal("decryptEnvelope() error = nil, want authentication error")
+			}
+		})
+	}
+}
+
+func TestParseEnvelope(t *testing.T) {
+	header := func(parts ...[]byte) []byte {
+		return append(append([]byte{}, magicEnvelope...), bytes.Join(parts, nil)...)
+	}
+
+	tests := []struct {
+		name        string
+		raw         []byte
+		wantKeyID   string
+		wantWrapped string
+		wantBody    string
+		wantErr     bool
+	}{
+		{name: "正常", raw: header([]byte{3}, []byte("kek"), []byte{0, 2}, []byte("wkbody")), wantKeyID: "kek", wantWrapped: "wk", wantBody: "body"},
+		{name: "形式が異なる", raw: append(append([]byte{}, magicV1...), 1, 'k'), wantErr: true},
+		{name: "ヘッダーのみ", raw: header(), wantErr: true},
+		{name: "鍵ID長が0", raw: header([]byte{0, 0, 0}), wantErr: true},
+		{name: "ラップ済みの鍵長がない", raw: header([]byte{3}, []byte("kek")), wantErr: true},
+		{name: "ラップ済みの鍵が途中で切れている", raw: header([]byte{3}, []byte("kek"), []byte{0, 9}, []byte("wk")), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyID, wrapped, _, body, err := parseEnvelope(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEnvelope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if keyID != tt.wantKeyID || string(wrapped) != tt.wantWrapped || string(body) != tt.wantBody {
+				t.Errorf("parseEnvelope() = %q, %q, %q, want %q, %q, %q", keyID, wrapped, body, tt.wantKeyID, tt.wantWrapped, tt.wantBody)
+			}
+		})
+	}
+}
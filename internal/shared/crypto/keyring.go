@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// LegacyKeyID は GOOGLE_TOKEN_ENC_KEY_BASE64 の鍵に割り当てる鍵IDです。
+const LegacyKeyID = "legacy"
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,32}$`)
+
+// keyring は復号に使用できる鍵の一覧と、暗号化に使用する鍵IDを保持する
+type keyring struct {
+	keys     map[string][]byte
+	order    []string
+	activeID string
+}
+
+// loadKeyring は環境変数から鍵を読み込む
+//
+//	GOOGLE_TOKEN_ENC_KEYS          "<鍵ID>:<Base64鍵>" をカンマ区切りで列挙（復号に使用）
+//	GOOGLE_TOKEN_ENC_ACTIVE_KEY_ID 暗号化に使用する鍵ID（未設定時は GOOGLE_TOKEN_ENC_KEYS の最後の鍵）
+//	GOOGLE_TOKEN_ENC_KEY_BASE64    旧形式の鍵。鍵ID "legacy" として扱う
+func loadKeyring() (*keyring, error) {
+	ring := &keyring{keys: make(map[string][]byte)}
+
+	if kb64 := strings.TrimSpace(os.Getenv("GOOGLE_TOKEN_ENC_KEY_BASE64")); kb64 != "" {
+		key, err := decodeKey(kb64)
+		if err != nil {
+			return nil, fmt.Errorf("GOOGLE_TOKEN_ENC_KEY_BASE64: %w", err)
+		}
+		ring.add(LegacyKeyID, key)
+	}
+
+	lastID := ""
+	for _, entry := range strings.Split(os.Getenv("GOOGLE_TOKEN_ENC_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, kb64, ok := strings.Cut(entry, ":")
+		id = strings.TrimSpace(id)
+		if !ok || !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("GOOGLE_TOKEN_ENC_KEYS: invalid entry %q (want <key id>:<base64 key>)", id)
+		}
+		if _, dup := ring.keys[id]; dup {
+			return nil, fmt.Errorf("GOOGLE_TOKEN_ENC_KEYS: duplicate key id %q", id)
+		}
+		key, err := decodeKey(kb64)
+		if err != nil {
+			return nil, fmt.Errorf("GOOGLE_TOKEN_ENC_KEYS[%s]: %w", id, err)
+		}
+		ring.add(id, key)
+		lastID = id
+	}
+
+	if len(ring.keys) == 0 {
+		return nil, errors.New("GOOGLE_TOKEN_ENC_KEYS / GOOGLE_TOKEN_ENC_KEY_BASE64 not set")
+	}
+
+	ring.activeID = strings.TrimSpace(os.Getenv("GOOGLE_TOKEN_ENC_ACTIVE_KEY_ID"))
+	switch {
+	case ring.activeID != "":
+		if _, ok := ring.keys[ring.activeID]; !ok {
+			return nil, fmt.Errorf("GOOGLE_TOKEN_ENC_ACTIVE_KEY_ID: %w: %s", ErrUnknownKeyID, ring.activeID)
+		}
+	case lastID != "":
+		ring.activeID = lastID
+	default:
+		ring.activeID = LegacyKeyID
+	}
+
+	return ring, nil
+}
+
+func (r *keyring) add(id string, key []byte) {
+	r.keys[id] = key
+	r.order = append(r.order, id)
+}
+
+// decodeKey はBase64の鍵をデコードし、AESの鍵長を検証する
+func decodeKey(kb64 string) ([]byte, error) {
+	// 改行・空白を除去（コピペ対策）
+	kb64 = strings.ReplaceAll(kb64, "\n", "")
+	kb64 = strings.ReplaceAll(kb64, "\r", "")
+	kb64 = strings.ReplaceAll(kb64, " ", "")
+
+	// まず標準Base64
+	key, err := base64.StdEncoding.DecodeString(kb64)
+	if err != nil {
+		// URL-safe やパディング無しの可能性に対応
+		if k2, err2 := base64.RawStdEncoding.DecodeString(kb64); err2 == nil {
+			key = k2
+		} else if k3, err3 := base64.RawURLEncoding.DecodeString(kb64); err3 == nil {
+			key = k3
+		} else {
+			return nil, fmt.Errorf("decode base64 failed: %w", err)
+		}
+	}
+	// 長さ検証（AES: 16/24/32）
+	if n := len(key); n != 16 && n != 24 && n != 32 {
+		return nil, fmt.Errorf("invalid key length: %d (must be 16/24/32 bytes)", n)
+	}
+	return key, nil
+}
@@ -0,0 +1,69 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// KeyManager はデータキーをラップ（暗号化）/アンラップする鍵管理サービスの抽象です。
+// クラウドKMSの実装は RegisterKeyManager で登録し、TOKEN_KMS_PROVIDER で選択します。
+type KeyManager interface {
+	// ActiveKeyID はラップに使用する鍵（KEK）のIDを返す
+	ActiveKeyID() string
+	// WrapKey はデータキーを有効なKEKでラップし、使用したKEKのIDとラップ済みの鍵を返す
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey は指定したKEKでラップ済みの鍵を復元する
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// KeyManagerFactory は環境変数などから KeyManager を生成する関数です。
+type KeyManagerFactory func() (KeyManager, error)
+
+var (
+	kmMu        sync.Mutex
+	kmFactories = map[string]KeyManagerFactory{
+		"local": NewLocalKeyManagerFromEnv,
+	}
+	kmInstance KeyManager
+)
+
+// RegisterKeyManager は TOKEN_KMS_PROVIDER で選択できる KeyManager を登録します。
+func RegisterKeyManager(name string, factory KeyManagerFactory) {
+	kmMu.Lock()
+	defer kmMu.Unlock()
+	kmFactories[name] = factory
+}
+
+// SetKeyManager はエンベロープ暗号化に使用する KeyManager を直接設定します。
+func SetKeyManager(km KeyManager) {
+	kmMu.Lock()
+	defer kmMu.Unlock()
+	kmInstance = km
+}
+
+// keyManager は設定済みの KeyManager を返す（未設定時は TOKEN_KMS_PROVIDER から生成する）
+func keyManager() (KeyManager, error) {
+	kmMu.Lock()
+	defer kmMu.Unlock()
+	if kmInstance != nil {
+		return kmInstance, nil
+	}
+
+	name := strings.TrimSpace(os.Getenv("TOKEN_KMS_PROVIDER"))
+	if name == "" {
+		name = "local"
+	}
+	factory, ok := kmFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("TOKEN_KMS_PROVIDER: unknown key manager %q", name)
+	}
+	km, err := factory()
+	if err != nil {
+		return nil, fmt.Errorf("key manager %s: %w", name, err)
+	}
+	kmInstance = km
+	return km, nil
+}
@@ -0,0 +1,86 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LocalKeyManager はファイルに保存したKEKでデータキーをラップする開発・テスト用の KeyManager です。
+//
+// 鍵ファイルの形式（TOKEN_KMS_LOCAL_KEY_FILE）:
+//
+//	{"active_key_id": "dev-2", "keys": {"dev-1": "<Base64鍵>", "dev-2": "<Base64鍵>"}}
+type LocalKeyManager struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+type localKeyFile struct {
+	ActiveKeyID string            `json:"active_key_id"`
+	Keys        map[string]string `json:"keys"`
+}
+
+// NewLocalKeyManagerFromEnv は TOKEN_KMS_LOCAL_KEY_FILE の鍵ファイルから LocalKeyManager を生成します。
+func NewLocalKeyManagerFromEnv() (KeyManager, error) {
+	path := strings.TrimSpace(os.Getenv("TOKEN_KMS_LOCAL_KEY_FILE"))
+	if path == "" {
+		return nil, errors.New("TOKEN_KMS_LOCAL_KEY_FILE not set")
+	}
+	return NewLocalKeyManagerFromFile(path)
+}
+
+// NewLocalKeyManagerFromFile は鍵ファイルから LocalKeyManager を生成します。
+func NewLocalKeyManagerFromFile(path string) (*LocalKeyManager, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	var f localKeyFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("parse key file: %w", err)
+	}
+
+	km := &LocalKeyManager{activeID: f.ActiveKeyID, keys: make(map[string][]byte, len(f.Keys))}
+	for id, kb64 := range f.Keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		key, err := decodeKey(kb64)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		km.keys[id] = key
+	}
+	if _, ok := km.keys[km.activeID]; !ok {
+		return nil, fmt.Errorf("active_key_id: %w: %q", ErrUnknownKeyID, km.activeID)
+	}
+	return km, nil
+}
+
+func (m *LocalKeyManager) ActiveKeyID() string { return m.activeID }
+
+func (m *LocalKeyManager) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	gcm, err := newGCM(m.keys[m.activeID])
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", nil, fmt.Errorf("nonce: %w", err)
+	}
+	return m.activeID, gcm.Seal(nonce, nonce, dataKey, []byte(m.activeID)), nil
+}
+
+func (m *LocalKeyManager) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := m.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, keyID)
+	}
+	return open(key, wrapped, []byte(keyID))
+}
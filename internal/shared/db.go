@@ -7,7 +7,10 @@ import (
 	"shakehandz-api/internal/auth"
 	"shakehandz-api/internal/extractor"
 	"shakehandz-api/internal/humanresource"
+	"shakehandz-api/internal/matching"
 	"shakehandz-api/internal/project"
+	"shakehandz-api/internal/shared/audit"
+	"shakehandz-api/internal/shared/jobqueue"
 	"shakehandz-api/internal/shared/options"
 
 	"gorm.io/driver/mysql"
@@ -33,9 +36,23 @@ func InitDB() *gorm.DB {
 		log.Fatal("DB接続失敗:", err)
 	}
 
-	if err := db.AutoMigrate(&project.Project{}, &humanresource.HumanResource{}, &auth.User{}, &auth.OauthToken{}, &options.Skills{}, &extractor.ExtractorBatchExecution{}); err != nil {
+	// 案件の必須スキルをテキストからJSON配列に変更する前に既存の値を変換する
+	if n, err := project.MigrateLegacySkills(db); err != nil {
+		log.Fatal("案件の必須スキルの変換失敗:", err)
+	} else if n > 0 {
+		log.Printf("案件の必須スキルをJSON配列に変換しました: %d件", n)
+	}
+
+	if err := db.AutoMigrate(&project.Project{}, &humanresource.HumanResource{}, &humanresource.CandidateCluster{}, &auth.User{}, &auth.OauthToken{}, &options.Skills{}, &extractor.ExtractorBatchExecution{}, &extractor.ExtractionFailure{}, &extractor.ProcessedMessage{}, &extractor.GmailSyncState{}, &extractor.ExtractorSetting{}, &jobqueue.Job{}, &audit.Log{}, &matching.MatchProposal{}); err != nil {
 		log.Fatal("マイグレーション失敗:", err)
 	}
 
+	// 作成者の導入前に抽出した案件の作成者を補完する
+	if n, err := extractor.BackfillProjectOwners(db); err != nil {
+		log.Printf("案件の作成者の補完に失敗しました: %v", err)
+	} else if n > 0 {
+		log.Printf("案件の作成者を補完しました: %d件", n)
+	}
+
 	return db
 }
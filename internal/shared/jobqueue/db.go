@@ -0,0 +1,174 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DBQueue はjobsテーブルを利用するキューです。
+// 取得時は SELECT ... FOR UPDATE SKIP LOCKED で複数ワーカー間の競合を避けます（MySQL 8.0以降）。
+type DBQueue struct {
+	DB *gorm.DB
+}
+
+// DBQueueがQueueを満たすことをコンパイル時に保証する
+var _ Queue = (*DBQueue)(nil)
+
+func NewDBQueue(db *gorm.DB) *DBQueue {
+	return &DBQueue{DB: db}
+}
+
+func (q *DBQueue) Enqueue(ctx context.Context, job *Job) error {
+	job.Status = StatusPending
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = 1
+	}
+	job.ActiveKey = job.activeKey()
+
+	return q.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 後続のジョブにキーを引き継ぐ
+		if job.Supersedes != 0 && job.ActiveKey != nil {
+			if err := tx.Model(&Job{}).Where("id = ? AND active_key = ?", job.Supersedes, *job.ActiveKey).Update("active_key", nil).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(job).Error; err != nil {
+			// 一意制約違反（同じキーのジョブが登録済み）かを確認する
+			if job.ActiveKey != nil {
+				var count int64
+				if tx.Model(&Job{}).Where("active_key = ?", *job.ActiveKey).Count(&count).Error == nil && count > 0 {
+					return ErrDuplicateKey
+				}
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (q *DBQueue) Dequeue(ctx context.Context, queue, owner string, visibility time.Duration) (*Job, error) {
+	var leased *Job
+
+	err := q.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// 実行可能な待機中ジョブ、またはリースが切れた実行中ジョブを1件ロックして取得
+		var job Job
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("queue = ?", queue).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND lease_expires_at < ?)", StatusPending, now, StatusRunning, now).
+			Order("run_at").
+			First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		expiresAt := now.Add(visibility)
+		job.Status = StatusRunning
+		job.LeaseOwner = owner
+		job.LeaseExpiresAt = &expiresAt
+		job.HeartbeatAt = &now
+		job.Attempts++
+
+		if err := tx.Model(&job).Select("status", "lease_owner", "lease_expires_at", "heartbeat_at", "attempts").Updates(&job).Error; err != nil {
+			return err
+		}
+		leased = &job
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return leased, nil
+}
+
+func (q *DBQueue) Heartbeat(ctx context.Context, job *Job, visibility time.Duration) error {
+	now := time.Now()
+	expiresAt := now.Add(visibility)
+
+	res := q.leased(ctx, job).Updates(map[string]interface{}{
+		"lease_expires_at": expiresAt,
+		"heartbeat_at":     now,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	job.LeaseExpiresAt = &expiresAt
+	job.HeartbeatAt = &now
+	return nil
+}
+
+func (q *DBQueue) Complete(ctx context.Context, job *Job) error {
+	return q.finish(ctx, job, map[string]interface{}{
+		"status":           StatusCompleted,
+		"active_key":       nil,
+		"lease_owner":      "",
+		"lease_expires_at": nil,
+	})
+}
+
+func (q *DBQueue) Retry(ctx context.Context, job *Job, runAt time.Time, cause error) error {
+	return q.finish(ctx, job, map[string]interface{}{
+		"status":           StatusPending,
+		"run_at":           runAt,
+		"attempts":         job.Attempts,
+		"lease_owner":      "",
+		"lease_expires_at": nil,
+		"last_error":       errorText(cause),
+	})
+}
+
+func (q *DBQueue) Fail(ctx context.Context, job *Job, cause error) error {
+	return q.finish(ctx, job, map[string]interface{}{
+		"status":           StatusFailed,
+		"active_key":       nil,
+		"lease_owner":      "",
+		"lease_expires_at": nil,
+		"last_error":       errorText(cause),
+	})
+}
+
+func (q *DBQueue) HasActive(ctx context.Context, queue, key string) (bool, error) {
+	var count int64
+	err := q.DB.WithContext(ctx).Model(&Job{}).
+		Where("queue = ? AND `key` = ? AND status IN ?", queue, key, []string{StatusPending, StatusRunning}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (q *DBQueue) CancelPending(ctx context.Context, queue, key string) (int, error) {
+	res := q.DB.WithContext(ctx).Model(&Job{}).
+		Where("queue = ? AND `key` = ? AND status = ?", queue, key, StatusPending).
+		Updates(map[string]interface{}{"status": StatusCancelled, "active_key": nil})
+	return int(res.RowsAffected), res.Error
+}
+
+// leased はリースを保持している実行中ジョブのみを更新対象とするクエリを返す
+func (q *DBQueue) leased(ctx context.Context, job *Job) *gorm.DB {
+	return q.DB.WithContext(ctx).Model(&Job{}).
+		Where("id = ? AND lease_owner = ? AND status = ?", job.ID, job.LeaseOwner, StatusRunning)
+}
+
+func (q *DBQueue) finish(ctx context.Context, job *Job, updates map[string]interface{}) error {
+	res := q.leased(ctx, job).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
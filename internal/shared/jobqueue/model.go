@@ -0,0 +1,71 @@
+// Package jobqueue は、プロセス再起動後も失われない永続ジョブキューを提供します。
+// DB（MySQL）をデフォルトのバックエンドとし、Redisも選択できます。
+package jobqueue
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ジョブのステータス
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+var (
+	// ErrLeaseLost はリースの有効期限切れなどにより、ジョブの実行権を失った場合のエラーです。
+	ErrLeaseLost = errors.New("jobqueue: lease lost")
+	// ErrNotFound はジョブが存在しない場合のエラーです。
+	ErrNotFound = errors.New("jobqueue: job not found")
+	// ErrDuplicateKey は同じキーの待機中・実行中ジョブが既に存在する場合のエラーです。
+	ErrDuplicateKey = errors.New("jobqueue: active job with the same key exists")
+)
+
+// Job はキューに登録された1件の処理です。
+// ワーカーはリース（LeaseExpiresAt）を取得して処理し、ハートビートで延長します。
+// リースが切れたジョブは他のワーカーに再配布されます（visibility timeout）。
+type Job struct {
+	ID     uint      `gorm:"primaryKey" json:"id"`
+	Queue  string    `gorm:"type:varchar(50);not null;index:idx_job_dequeue,priority:1" json:"queue"`
+	Status string    `gorm:"type:varchar(20);not null;index:idx_job_dequeue,priority:2" json:"status"`
+	RunAt  time.Time `gorm:"type:datetime(3);not null;index:idx_job_dequeue,priority:3" json:"run_at"`
+
+	// 同一対象のジョブを重複登録しないためのキー（例: ユーザーID:抽出種別）
+	Key string `gorm:"type:varchar(191);index" json:"key"`
+	// 待機中・実行中の間だけ「キュー名:キー」を保持し、一意制約で同じキーのジョブの同時登録を防ぐ
+	ActiveKey *string `gorm:"type:varchar(255);uniqueIndex" json:"-"`
+	// Supersedes は同じキーを引き継ぐ実行中ジョブのID（実行中のジョブから後続のジョブを登録する場合に指定）
+	Supersedes uint           `gorm:"-" json:"-"`
+	Payload    datatypes.JSON `gorm:"type:json" json:"payload"`
+
+	Attempts    int `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int `gorm:"not null;default:1" json:"max_attempts"`
+
+	LeaseOwner     string     `gorm:"type:varchar(191)" json:"lease_owner,omitempty"`
+	LeaseExpiresAt *time.Time `gorm:"type:datetime(3);index" json:"lease_expires_at,omitempty"`
+	HeartbeatAt    *time.Time `gorm:"type:datetime(3)" json:"heartbeat_at,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// activeKey は同じキーのジョブを判定するための値を返します。キーが無い場合は nil です。
+func (j *Job) activeKey() *string {
+	if j.Key == "" {
+		return nil
+	}
+	k := j.Queue + ":" + j.Key
+	return &k
+}
+
+// TableName はGORMにテーブル名を明示的に指定します。
+func (Job) TableName() string {
+	return "jobs"
+}
@@ -0,0 +1,54 @@
+package jobqueue
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Queue は永続ジョブキューのバックエンドが満たすインターフェースです。
+type Queue interface {
+	// Enqueue はジョブを登録し、job.IDを設定します。
+	// 同じキーの待機中・実行中ジョブ（job.Supersedes を除く）が存在する場合は ErrDuplicateKey を返します。
+	Enqueue(ctx context.Context, job *Job) error
+	// Dequeue は実行可能なジョブを1件リースして返します。無い場合は nil, nil を返します。
+	Dequeue(ctx context.Context, queue, owner string, visibility time.Duration) (*Job, error)
+	// Heartbeat はリースを延長します。実行権を失っている場合は ErrLeaseLost を返します。
+	Heartbeat(ctx context.Context, job *Job, visibility time.Duration) error
+	// Complete はジョブを完了にします。
+	Complete(ctx context.Context, job *Job) error
+	// Retry はジョブをrunAtに再実行するよう待機状態へ戻します。job.Attempts もあわせて保存します。
+	Retry(ctx context.Context, job *Job, runAt time.Time, cause error) error
+	// Fail はジョブを失敗にします（再実行しません）。
+	Fail(ctx context.Context, job *Job, cause error) error
+	// HasActive は同じキーの待機中・実行中ジョブが存在するかを返します。
+	HasActive(ctx context.Context, queue, key string) (bool, error)
+	// CancelPending は同じキーの待機中ジョブを取り消し、取り消した件数を返します。
+	// 実行中のジョブは対象外です（ハンドラ側で中断を検知してください）。
+	CancelPending(ctx context.Context, queue, key string) (int, error)
+}
+
+const (
+	BackendDB    = "db"
+	BackendRedis = "redis"
+)
+
+// NewFromEnv は JOB_QUEUE_BACKEND に応じてキューを生成します。
+// redis を指定してもRedisクライアントが無い場合はDBを利用します。
+func NewFromEnv(db *gorm.DB, rdb *redis.Client) Queue {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("JOB_QUEUE_BACKEND")), BackendRedis) && rdb != nil {
+		return NewRedisQueue(rdb)
+	}
+	return NewDBQueue(db)
+}
+
+func errorText(cause error) string {
+	if cause == nil {
+		return ""
+	}
+	return cause.Error()
+}
@@ -0,0 +1,299 @@
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 完了・失敗したジョブをRedisに残しておく期間
+const redisFinishedTTL = 24 * time.Hour
+
+// RedisQueue はRedisのsorted setを利用するキューです。
+//   - jobqueue:<queue>:pending  待機中ジョブ（score = 実行予定時刻）
+//   - jobqueue:<queue>:running  実行中ジョブ（score = リース期限）
+//   - jobqueue:<queue>:active   キー → 待機中・実行中ジョブID
+//   - jobqueue:job:<id>         ジョブ本体（JSON）
+type RedisQueue struct {
+	RDB *redis.Client
+}
+
+// RedisQueueがQueueを満たすことをコンパイル時に保証する
+var _ Queue = (*RedisQueue)(nil)
+
+func NewRedisQueue(rdb *redis.Client) *RedisQueue {
+	return &RedisQueue{RDB: rdb}
+}
+
+// リース切れの実行中ジョブを待機中に戻したうえで、実行可能なジョブを1件実行中へ移す
+var dequeueScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(expired) do
+  redis.call('ZREM', KEYS[2], id)
+  redis.call('ZADD', KEYS[1], ARGV[1], id)
+end
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #ids == 0 then
+  return false
+end
+redis.call('ZREM', KEYS[1], ids[1])
+redis.call('ZADD', KEYS[2], ARGV[2], ids[1])
+return ids[1]
+`)
+
+// 同じキーの待機中・実行中ジョブが無い場合（または引き継ぎ元のジョブの場合）のみジョブを登録する
+var enqueueScript = redis.NewScript(`
+if ARGV[4] ~= '' then
+  local current = redis.call('HGET', KEYS[2], ARGV[4])
+  if current and current ~= ARGV[5] then
+    return 0
+  end
+  redis.call('HSET', KEYS[2], ARGV[4], ARGV[1])
+end
+redis.call('SET', KEYS[3], ARGV[2])
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+return 1
+`)
+
+// 実行中のジョブのみリースを延長する
+var heartbeatScript = redis.NewScript(`
+if redis.call('ZSCORE', KEYS[1], ARGV[1]) == false then
+  return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+return 1
+`)
+
+func pendingKey(queue string) string { return "jobqueue:" + queue + ":pending" }
+func runningKey(queue string) string { return "jobqueue:" + queue + ":running" }
+func activeKey(queue string) string  { return "jobqueue:" + queue + ":active" }
+func jobKey(id uint) string          { return fmt.Sprintf("jobqueue:job:%d", id) }
+
+func (q *RedisQueue) Enqueue(ctx context.Context, job *Job) error {
+	id, err := q.RDB.Incr(ctx, "jobqueue:seq").Result()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	job.ID = uint(id)
+	job.Status = StatusPending
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = 1
+	}
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	ok, err := enqueueScript.Run(ctx, q.RDB,
+		[]string{pendingKey(job.Queue), activeKey(job.Queue), jobKey(job.ID)},
+		job.ID, b, job.RunAt.UnixMilli(), job.Key, job.Supersedes,
+	).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrDuplicateKey
+	}
+	return nil
+}
+
+func (q *RedisQueue) Dequeue(ctx context.Context, queue, owner string, visibility time.Duration) (*Job, error) {
+	now := time.Now()
+	expiresAt := now.Add(visibility)
+
+	res, err := dequeueScript.Run(ctx, q.RDB,
+		[]string{pendingKey(queue), runningKey(queue)},
+		now.UnixMilli(), expiresAt.UnixMilli(),
+	).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseUint(res, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	job, err := q.load(ctx, uint(id))
+	if errors.Is(err, ErrNotFound) {
+		// 本体が失われたジョブは配布対象から外す
+		q.RDB.ZRem(ctx, runningKey(queue), res)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	job.Status = StatusRunning
+	job.LeaseOwner = owner
+	job.LeaseExpiresAt = &expiresAt
+	job.HeartbeatAt = &now
+	job.Attempts++
+	if err := q.save(ctx, job, 0); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (q *RedisQueue) Heartbeat(ctx context.Context, job *Job, visibility time.Duration) error {
+	now := time.Now()
+	expiresAt := now.Add(visibility)
+
+	ok, err := heartbeatScript.Run(ctx, q.RDB, []string{runningKey(job.Queue)}, job.ID, expiresAt.UnixMilli()).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLeaseLost
+	}
+	if err := q.checkOwner(ctx, job); err != nil {
+		return err
+	}
+
+	job.LeaseExpiresAt = &expiresAt
+	job.HeartbeatAt = &now
+	return q.save(ctx, job, 0)
+}
+
+func (q *RedisQueue) Complete(ctx context.Context, job *Job) error {
+	if err := q.release(ctx, job); err != nil {
+		return err
+	}
+	job.Status = StatusCompleted
+	q.clearActive(ctx, job)
+	return q.save(ctx, job, redisFinishedTTL)
+}
+
+func (q *RedisQueue) Retry(ctx context.Context, job *Job, runAt time.Time, cause error) error {
+	if err := q.release(ctx, job); err != nil {
+		return err
+	}
+	job.Status = StatusPending
+	job.RunAt = runAt
+	job.LastError = errorText(cause)
+	if err := q.save(ctx, job, 0); err != nil {
+		return err
+	}
+	return q.RDB.ZAdd(ctx, pendingKey(job.Queue), redis.Z{Score: float64(runAt.UnixMilli()), Member: job.ID}).Err()
+}
+
+func (q *RedisQueue) Fail(ctx context.Context, job *Job, cause error) error {
+	if err := q.release(ctx, job); err != nil {
+		return err
+	}
+	job.Status = StatusFailed
+	job.LastError = errorText(cause)
+	q.clearActive(ctx, job)
+	return q.save(ctx, job, redisFinishedTTL)
+}
+
+func (q *RedisQueue) HasActive(ctx context.Context, queue, key string) (bool, error) {
+	n, err := q.RDB.HExists(ctx, activeKey(queue), key).Result()
+	return n, err
+}
+
+func (q *RedisQueue) CancelPending(ctx context.Context, queue, key string) (int, error) {
+	id, err := q.RDB.HGet(ctx, activeKey(queue), key).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// 待機中セットから外せた場合のみ取り消す（実行中のジョブは対象外）
+	n, err := q.RDB.ZRem(ctx, pendingKey(queue), id).Result()
+	if err != nil || n == 0 {
+		return 0, err
+	}
+
+	job, err := q.load(ctx, uint(id))
+	if err != nil {
+		return 0, err
+	}
+	job.Status = StatusCancelled
+	q.clearActive(ctx, job)
+	if err := q.save(ctx, job, redisFinishedTTL); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// release は実行中セットからジョブを外す。既に外れている場合は実行権を失っている
+func (q *RedisQueue) release(ctx context.Context, job *Job) error {
+	if err := q.checkOwner(ctx, job); err != nil {
+		return err
+	}
+	n, err := q.RDB.ZRem(ctx, runningKey(job.Queue), job.ID).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	job.LeaseOwner = ""
+	job.LeaseExpiresAt = nil
+	return nil
+}
+
+// checkOwner はリースが他のワーカーに移っていないことを確認する
+func (q *RedisQueue) checkOwner(ctx context.Context, job *Job) error {
+	current, err := q.load(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	if current.Status != StatusRunning || current.LeaseOwner != job.LeaseOwner {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (q *RedisQueue) clearActive(ctx context.Context, job *Job) {
+	if job.Key == "" {
+		return
+	}
+	// 同じキーで新しいジョブが登録されている場合は消さない
+	id, err := q.RDB.HGet(ctx, activeKey(job.Queue), job.Key).Uint64()
+	if err == nil && uint(id) == job.ID {
+		q.RDB.HDel(ctx, activeKey(job.Queue), job.Key)
+	}
+}
+
+func (q *RedisQueue) load(ctx context.Context, id uint) (*Job, error) {
+	b, err := q.RDB.Get(ctx, jobKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal(b, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (q *RedisQueue) save(ctx context.Context, job *Job, ttl time.Duration) error {
+	job.UpdatedAt = time.Now()
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return q.RDB.Set(ctx, jobKey(job.ID), b, ttl).Err()
+}
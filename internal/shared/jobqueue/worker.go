@@ -0,0 +1,217 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Handler はジョブを処理する関数です。
+// エラーを返すと試行回数が残っている限り再実行されます。
+// ワーカーの停止（ctxのキャンセル）による中断と RetryAfter による待機は試行回数に数えません。
+type Handler func(ctx context.Context, job *Job) error
+
+// permanentError は再実行しても成功しないエラーを表す
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent はエラーを再実行不要としてマークします。
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent はエラーが Permanent でマークされているかを返します。
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// delayedError は指定時間後に再実行するエラーを表す
+type delayedError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *delayedError) Error() string { return e.err.Error() }
+func (e *delayedError) Unwrap() error { return e.err }
+
+// RetryAfter はエラーを、Backoffの代わりにdelay後に再実行するようマークします。
+// 割り当て超過など、回復までの時間がわかっている場合に利用します。
+func RetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &delayedError{err: err, delay: delay}
+}
+
+// Worker はキューからジョブを取得して並列に処理します。
+type Worker struct {
+	Queue        Queue
+	QueueName    string
+	Handler      Handler
+	Concurrency  int
+	Visibility   time.Duration // リースの有効期間
+	PollInterval time.Duration // ジョブが無いときの待機時間
+	// Backoff は失敗時の再実行までの待機時間を返します（attemptは1始まり）
+	Backoff func(attempt int) time.Duration
+	// ID はリースの所有者を識別する値です。空の場合は自動で設定されます
+	ID string
+}
+
+// DefaultBackoff は30秒から始まり最大10分まで倍増する待機時間を返します。
+func DefaultBackoff(attempt int) time.Duration {
+	d := 30 * time.Second
+	for i := 1; i < attempt && d < 10*time.Minute; i++ {
+		d *= 2
+	}
+	if d > 10*time.Minute {
+		d = 10 * time.Minute
+	}
+	return d
+}
+
+// Run はctxがキャンセルされるまでジョブを処理し続けます。
+// 実行中のジョブの終了を待ってから戻ります。
+func (w *Worker) Run(ctx context.Context) {
+	if w.Concurrency <= 0 {
+		w.Concurrency = 1
+	}
+	if w.Visibility <= 0 {
+		w.Visibility = 5 * time.Minute
+	}
+	if w.PollInterval <= 0 {
+		w.PollInterval = 5 * time.Second
+	}
+	if w.Backoff == nil {
+		w.Backoff = DefaultBackoff
+	}
+	if w.ID == "" {
+		host, _ := os.Hostname()
+		w.ID = fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
+	}
+
+	log.Printf("ジョブワーカー起動: queue=%s worker=%s concurrency=%d", w.QueueName, w.ID, w.Concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+
+	log.Printf("ジョブワーカー停止: queue=%s worker=%s", w.QueueName, w.ID)
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := w.Queue.Dequeue(ctx, w.QueueName, w.ID, w.Visibility)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("ジョブ取得失敗: %v", err)
+			}
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.PollInterval):
+			}
+			continue
+		}
+
+		w.process(ctx, job)
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job *Job) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// リースを保持している間、定期的にハートビートを送る
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(w.Visibility / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				if err := w.Queue.Heartbeat(jobCtx, job, w.Visibility); err != nil {
+					log.Printf("ハートビート失敗: job=%d err=%v", job.ID, err)
+					if errors.Is(err, ErrLeaseLost) {
+						cancel()
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	err := w.runHandler(jobCtx, job)
+	close(done)
+
+	// 停止中でも結果を書き戻せるよう、親のキャンセルから切り離す
+	finishCtx, finishCancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer finishCancel()
+
+	var de *delayedError
+	switch {
+	case err == nil:
+		err = w.Queue.Complete(finishCtx, job)
+	case ctx.Err() != nil:
+		// ワーカーの停止による中断はジョブの失敗ではないため、試行回数を消費せず直ちに再実行する
+		job.Attempts--
+		log.Printf("ワーカー停止のためジョブを戻します: job=%d err=%v", job.ID, err)
+		err = w.Queue.Retry(finishCtx, job, time.Now(), err)
+	case IsPermanent(err):
+		log.Printf("ジョブ失敗: job=%d attempts=%d err=%v", job.ID, job.Attempts, err)
+		err = w.Queue.Fail(finishCtx, job, err)
+	case errors.As(err, &de):
+		// 割り当て超過など回復までの時間がわかっている待機は試行回数に数えない
+		job.Attempts--
+		runAt := time.Now().Add(de.delay)
+		log.Printf("ジョブ再実行予定: job=%d attempts=%d run_at=%s err=%v", job.ID, job.Attempts, runAt.Format(time.RFC3339), err)
+		err = w.Queue.Retry(finishCtx, job, runAt, err)
+	case job.Attempts >= job.MaxAttempts:
+		log.Printf("ジョブ失敗: job=%d attempts=%d err=%v", job.ID, job.Attempts, err)
+		err = w.Queue.Fail(finishCtx, job, err)
+	default:
+		runAt := time.Now().Add(w.Backoff(job.Attempts))
+		log.Printf("ジョブ再実行予定: job=%d attempts=%d run_at=%s err=%v", job.ID, job.Attempts, runAt.Format(time.RFC3339), err)
+		err = w.Queue.Retry(finishCtx, job, runAt, err)
+	}
+	if err != nil {
+		log.Printf("ジョブ結果の保存失敗: job=%d err=%v", job.ID, err)
+	}
+}
+
+// runHandler はハンドラのpanicをエラーに変換する
+func (w *Worker) runHandler(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jobqueue: handler panic: %v", r)
+		}
+	}()
+	return w.Handler(ctx, job)
+}
@@ -0,0 +1,99 @@
+// Package fake は、テストやオフライン開発向けに決められたレスポンスを返すllm.Providerの実装です。
+package fake
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"shakehandz-api/internal/shared/llm"
+)
+
+// Rule は入力にKeyが含まれる場合に返却するレスポンスです。
+type Rule struct {
+	Key      string
+	Response string
+	Err      error
+}
+
+// Provider はネットワークを使わずにレスポンスを再生するllm.Providerです。
+// 並列実行でも結果が変わらないよう、呼び出し順ではなく入力内容（メッセージIDなど）でレスポンスを選びます。
+type Provider struct {
+	mu       sync.Mutex
+	rules    []Rule
+	fallback string
+	calls    []llm.Request
+}
+
+// Providerがllm.Providerを満たすことをコンパイル時に保証する
+var _ llm.Provider = (*Provider)(nil)
+
+// NewProvider はルールに一致しない入力に対してfallbackを返すProviderを生成します。
+func NewProvider(fallback string, rules ...Rule) *Provider {
+	return &Provider{rules: rules, fallback: fallback}
+}
+
+// NewProviderFromDir はディレクトリ内の *.json を読み込み、ファイル名（拡張子除く）をキーとしたProviderを生成します。
+// default.json が存在する場合はfallbackとして利用します。
+func NewProviderFromDir(dir string) (*Provider, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	p := NewProvider("[]")
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", f, err)
+		}
+		key := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		if key == "default" {
+			p.fallback = string(b)
+			continue
+		}
+		p.rules = append(p.rules, Rule{Key: key, Response: string(b)})
+	}
+	return p, nil
+}
+
+// When は入力にkeyが含まれる場合に返却するレスポンスを登録します。
+func (p *Provider) When(key, response string) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = append(p.rules, Rule{Key: key, Response: response})
+	return p
+}
+
+// GenerateJSON は登録順に最初に一致したルールのレスポンスを返します。
+func (p *Provider) GenerateJSON(ctx context.Context, req llm.Request) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, req)
+
+	for _, r := range p.rules {
+		if strings.Contains(req.Input, r.Key) {
+			if r.Err != nil {
+				return "", r.Err
+			}
+			return r.Response, nil
+		}
+	}
+	return p.fallback, nil
+}
+
+// Calls はこれまでに受け付けたリクエストを返します。
+func (p *Provider) Calls() []llm.Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]llm.Request(nil), p.calls...)
+}
@@ -21,7 +21,7 @@ func NewGeminiClientWithRefresh(ctx context.Context, model string, encRefresh []
 	}
 
 	// 1. リフレッシュトークンを復号
-	rt, err := crypto.DecryptFromBytes(encRefresh)
+	rt, err := crypto.DecryptFromBytesContext(ctx, encRefresh)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,43 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+
+	"shakehandz-api/internal/shared/llm"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// Clientがllm.Providerを満たすことをコンパイル時に保証する
+var _ llm.Provider = (*Client)(nil)
+
+// GenerateJSON はSystemInstructionを設定したモデルで入力を構造化し、JSON文字列を返す
+func (c *Client) GenerateJSON(ctx context.Context, req llm.Request) (string, error) {
+	// 共有モデルの浅いコピーを作成してSystemInstructionを設定
+	localModel := *c.Model
+	localModel.SystemInstruction = &genai.Content{
+		Role:  "system",
+		Parts: []genai.Part{genai.Text(req.SystemPrompt)},
+	}
+
+	// スキーマ指定時はJSONモードで返却させ、前後の文章やコードブロックを排除する
+	if req.Schema != nil {
+		localModel.ResponseMIMEType = "application/json"
+		localModel.ResponseSchema = ToGenaiSchema(req.Schema)
+	}
+
+	resp, err := localModel.GenerateContent(ctx, genai.Text(req.Input))
+	if err != nil {
+		return "", fmt.Errorf("Gemini API 呼び出し失敗: %w", err)
+	}
+
+	// Geminiのレスポンスから文字列を抽出
+	text, ok := ExtractText(resp)
+	if !ok {
+		return "", llm.ErrEmptyResponse
+	}
+
+	// Geminiのレスポンスから前後の不要な文字列をトリム
+	return TrimPrefixAndSuffixGeminiResponse(text), nil
+}
@@ -0,0 +1,42 @@
+package gemini
+
+import (
+	"shakehandz-api/internal/shared/llm"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+var schemaTypes = map[llm.Type]genai.Type{
+	llm.TypeObject:  genai.TypeObject,
+	llm.TypeArray:   genai.TypeArray,
+	llm.TypeString:  genai.TypeString,
+	llm.TypeInteger: genai.TypeInteger,
+	llm.TypeNumber:  genai.TypeNumber,
+	llm.TypeBoolean: genai.TypeBoolean,
+}
+
+// ToGenaiSchema はllm.SchemaをGeminiのResponseSchemaに変換する
+func ToGenaiSchema(s *llm.Schema) *genai.Schema {
+	if s == nil {
+		return nil
+	}
+
+	gs := &genai.Schema{
+		Type:        schemaTypes[s.Type],
+		Description: s.Description,
+		Nullable:    s.Nullable,
+		Enum:        s.Enum,
+		Items:       ToGenaiSchema(s.Items),
+		Required:    s.Required,
+	}
+	if len(s.Enum) > 0 {
+		gs.Format = "enum"
+	}
+	if len(s.Properties) > 0 {
+		gs.Properties = make(map[string]*genai.Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			gs.Properties[name] = ToGenaiSchema(prop)
+		}
+	}
+	return gs
+}
@@ -0,0 +1,178 @@
+// Package openai は、OpenAI互換のChat Completions APIを利用するllm.Providerの実装です。
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"shakehandz-api/internal/shared/llm"
+)
+
+const (
+	defaultBaseURL = "https://api.openai.com/v1"
+	// 配列スキーマをラップする際のキー
+	wrapperKey = "items"
+)
+
+// Client はOpenAI互換APIのクライアントです。
+type Client struct {
+	BaseURL    string
+	APIKey     string
+	Model      string
+	HTTPClient *http.Client
+}
+
+// Clientがllm.Providerを満たすことをコンパイル時に保証する
+var _ llm.Provider = (*Client)(nil)
+
+// NewClientFromEnv は環境変数（OPENAI_BASE_URL, OPENAI_API_KEY, OPENAI_MODEL）からクライアントを生成します。
+func NewClientFromEnv() (*Client, error) {
+	model := strings.TrimSpace(os.Getenv("OPENAI_MODEL"))
+	if model == "" {
+		return nil, errors.New("OPENAI_MODEL not set")
+	}
+
+	baseURL := strings.TrimSpace(os.Getenv("OPENAI_BASE_URL"))
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		APIKey:     os.Getenv("OPENAI_API_KEY"),
+		Model:      model,
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	Temperature    float64         `json:"temperature"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// GenerateJSON はChat Completions APIを呼び出し、JSON文字列を返します。
+func (c *Client) GenerateJSON(ctx context.Context, req llm.Request) (string, error) {
+	payload := chatRequest{
+		Model: c.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: req.SystemPrompt},
+			{Role: "user", Content: req.Input},
+		},
+		Temperature: 0,
+	}
+
+	// json_schemaはルートがobjectである必要があるため、配列は items でラップする
+	wrapped := req.Schema != nil && req.Schema.Type == llm.TypeArray
+	if req.Schema != nil {
+		root := req.Schema
+		if wrapped {
+			root = &llm.Schema{
+				Type:       llm.TypeObject,
+				Properties: map[string]*llm.Schema{wrapperKey: req.Schema},
+				Required:   []string{wrapperKey},
+			}
+		}
+		payload.ResponseFormat = &responseFormat{
+			Type:       "json_schema",
+			JSONSchema: &jsonSchema{Name: "response", Schema: ToJSONSchema(root)},
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	res, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("OpenAI互換API 呼び出し失敗: %w", err)
+	}
+	defer res.Body.Close()
+
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", &APIError{StatusCode: res.StatusCode, Body: string(raw)}
+	}
+
+	var parsed chatResponse
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	if len(parsed.Choices) == 0 || parsed.Choices[0].Message.Content == "" {
+		return "", llm.ErrEmptyResponse
+	}
+
+	content := llm.TrimJSONFence(parsed.Choices[0].Message.Content)
+	if !wrapped {
+		return content, nil
+	}
+
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &envelope); err != nil {
+		return "", fmt.Errorf("unmarshal wrapped content: %w", err)
+	}
+	items, ok := envelope[wrapperKey]
+	if !ok {
+		return "", llm.ErrEmptyResponse
+	}
+	return string(items), nil
+}
+
+// APIError はOpenAI互換APIが200以外を返した場合のエラーです。
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+// HTTPStatus はリトライ判定のためにHTTPステータスコードを返します。
+func (e *APIError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("openai: status %d: %s", e.StatusCode, e.Body)
+}
@@ -0,0 +1,44 @@
+package openai
+
+import "shakehandz-api/internal/shared/llm"
+
+// ToJSONSchema はllm.SchemaをJSON Schema形式に変換する
+func ToJSONSchema(s *llm.Schema) map[string]any {
+	if s == nil {
+		return nil
+	}
+
+	out := map[string]any{}
+	if s.Nullable {
+		out["type"] = []string{string(s.Type), "null"}
+	} else {
+		out["type"] = string(s.Type)
+	}
+	if s.Description != "" {
+		out["description"] = s.Description
+	}
+	if len(s.Enum) > 0 {
+		enum := make([]any, 0, len(s.Enum)+1)
+		for _, v := range s.Enum {
+			enum = append(enum, v)
+		}
+		if s.Nullable {
+			enum = append(enum, nil)
+		}
+		out["enum"] = enum
+	}
+	if s.Items != nil {
+		out["items"] = ToJSONSchema(s.Items)
+	}
+	if s.Type == llm.TypeObject {
+		props := make(map[string]any, len(s.Properties))
+		for name, prop := range s.Properties {
+			props[name] = ToJSONSchema(prop)
+		}
+		out["properties"] = props
+		if len(s.Required) > 0 {
+			out["required"] = s.Required
+		}
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+// Package llm は、抽出処理などで利用するLLMの共通インターフェースを定義します。
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrEmptyResponse はLLMから文字列を取り出せなかった場合のエラーです。
+var ErrEmptyResponse = errors.New("llm: empty response")
+
+// Request はLLMへの1回分の生成リクエストです。
+type Request struct {
+	// SystemPrompt はモデルへのシステム指示（prompts配下のテンプレート）
+	SystemPrompt string
+	// Input はモデルへ渡す入力（メールのJSON配列など）
+	Input string
+	// Schema を指定した場合、Providerはスキーマに沿ったJSONのみを返却させる
+	Schema *Schema
+}
+
+// Provider はシステムプロンプトと入力から構造化JSONを生成するLLMの共通インターフェースです。
+// Gemini / OpenAI互換API / テスト用のFakeを差し替えて利用します。
+type Provider interface {
+	GenerateJSON(ctx context.Context, req Request) (string, error)
+}
+
+// TrimJSONFence はレスポンス前後のマークダウンのコードブロック記号を除去します。
+func TrimJSONFence(target string) string {
+	cleaned := strings.TrimSpace(target)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+
+	return strings.TrimSpace(cleaned)
+}
@@ -0,0 +1,43 @@
+// Package provider は、環境変数 LLM_PROVIDER に応じてllm.Providerを生成します。
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"shakehandz-api/internal/shared/llm"
+	"shakehandz-api/internal/shared/llm/fake"
+	"shakehandz-api/internal/shared/llm/gemini"
+	"shakehandz-api/internal/shared/llm/openai"
+)
+
+const (
+	Gemini = "gemini"
+	OpenAI = "openai"
+	Fake   = "fake"
+)
+
+// New はLLM_PROVIDERに応じたProviderを生成します（未設定時はGemini）。
+// Geminiはユーザーのリフレッシュトークン、OpenAI互換APIはOPENAI_*、FakeはLLM_FAKE_RESPONSES_DIRを利用します。
+func New(ctx context.Context, model string, encRefresh []byte) (llm.Provider, error) {
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv("LLM_PROVIDER"))); name {
+	case "", Gemini:
+		cli, err := gemini.NewGeminiClientWithRefresh(ctx, model, encRefresh)
+		if err != nil {
+			return nil, err
+		}
+		return cli, nil
+	case OpenAI:
+		return openai.NewClientFromEnv()
+	case Fake:
+		dir := os.Getenv("LLM_FAKE_RESPONSES_DIR")
+		if dir == "" {
+			return fake.NewProvider("[]"), nil
+		}
+		return fake.NewProviderFromDir(dir)
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER: %s", name)
+	}
+}
@@ -0,0 +1,36 @@
+package llm
+
+import (
+	"context"
+
+	"shakehandz-api/internal/shared/retry"
+)
+
+// Waiter は呼び出し前に待機するレートリミッターです（*rate.Limiter が満たします）。
+type Waiter interface {
+	Wait(ctx context.Context) error
+}
+
+// retryingProvider は呼び出しごとにレート制限とリトライを行うProvider
+type retryingProvider struct {
+	Provider
+	policy  retry.Policy
+	limiter Waiter
+}
+
+// WithRetry は一時的なエラー（429/503など）をリトライするProviderを返します。
+// limiterを指定した場合は各呼び出しの前にトークンを取得します（リトライ時も同様）。
+func WithRetry(p Provider, policy retry.Policy, limiter Waiter) Provider {
+	return &retryingProvider{Provider: p, policy: policy, limiter: limiter}
+}
+
+func (r *retryingProvider) GenerateJSON(ctx context.Context, req Request) (string, error) {
+	return retry.DoValue(ctx, r.policy, "LLM呼び出し", func(ctx context.Context) (string, error) {
+		if r.limiter != nil {
+			if err := r.limiter.Wait(ctx); err != nil {
+				return "", err
+			}
+		}
+		return r.Provider.GenerateJSON(ctx, req)
+	})
+}
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Type はレスポンススキーマのデータ型です。
+type Type string
+
+const (
+	TypeObject  Type = "object"
+	TypeArray   Type = "array"
+	TypeString  Type = "string"
+	TypeInteger Type = "integer"
+	TypeNumber  Type = "number"
+	TypeBoolean Type = "boolean"
+)
+
+// Schema はLLMに返却させるJSONの構造です。各Providerが自身の形式へ変換して利用します。
+type Schema struct {
+	Type        Type
+	Description string
+	Nullable    bool
+	Enum        []string
+	Items       *Schema
+	Properties  map[string]*Schema
+	Required    []string
+}
+
+// Enumerable は列挙型が取り得る値を返すためのインターフェースです。
+// 実装した型はスキーマ生成時に enum として出力されます。
+type Enumerable interface {
+	EnumValues() []string
+}
+
+var (
+	enumerableType = reflect.TypeOf((*Enumerable)(nil)).Elem()
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+// ArrayOf は items を要素とする配列のスキーマを返します。
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: TypeArray, Items: items}
+}
+
+// SchemaFromStruct は構造体のjsonタグからスキーマを生成します。
+// `json:"-"` または `llm:"-"` のフィールドは除外し、ポインタ型はnullableとして扱います。
+func SchemaFromStruct(v any) *Schema {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return schemaFromType(t)
+}
+
+func schemaFromType(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		nullable = true
+		t = t.Elem()
+	}
+
+	var s *Schema
+	switch {
+	case t.Implements(enumerableType):
+		s = &Schema{Type: TypeString, Enum: reflect.Zero(t).Interface().(Enumerable).EnumValues()}
+	case t == timeType:
+		s = &Schema{Type: TypeString}
+	default:
+		switch t.Kind() {
+		case reflect.String:
+			s = &Schema{Type: TypeString}
+		case reflect.Bool:
+			s = &Schema{Type: TypeBoolean}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			s = &Schema{Type: TypeInteger}
+		case reflect.Float32, reflect.Float64:
+			s = &Schema{Type: TypeNumber}
+		case reflect.Slice, reflect.Array:
+			s = ArrayOf(schemaFromType(t.Elem()))
+		case reflect.Struct:
+			s = objectSchema(t)
+		default:
+			s = &Schema{Type: TypeString}
+		}
+	}
+
+	s.Nullable = nullable
+	return s
+}
+
+func objectSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: TypeObject, Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Tag.Get("llm") == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := schemaFromType(f.Type)
+		s.Properties[name] = prop
+		if !prop.Nullable {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
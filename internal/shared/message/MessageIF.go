@@ -17,4 +17,6 @@ type Attachment struct {
 	Filename     string `json:"filename"`
 	Size         int64  `json:"size"`
 	AttachmentID string `json:"attachment_id"`
+	// 添付ファイル（PDF/Excel/Word）から抽出したテキスト。抽出対象外の場合は空
+	Text string `json:"text,omitempty"`
 }
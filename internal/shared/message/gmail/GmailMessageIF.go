@@ -8,8 +8,8 @@ import (
 )
 
 type MsgIDFetcherIF interface {
-	FetchMsgIds(svc *gmail.Service, query string, max int64) ([]*gmail.Message, error)
-	FetchMsgIdsWithPaging(svc *gmail.Service, query string, pageSize int64, pageToken string) ([]*gmail.Message, string, error)
+	FetchMsgIds(ctx context.Context, svc *gmail.Service, query string, max int64) ([]*gmail.Message, error)
+	FetchMsgIdsWithPaging(ctx context.Context, svc *gmail.Service, query string, pageSize int64, pageToken string) ([]*gmail.Message, string, error)
 }
 
 type MsgDetailFetcherIF interface {
@@ -21,8 +21,19 @@ type MessageFetcherIF interface {
 	FetchMsgWithPaging(ctx context.Context, svc *gmail.Service, query string, pageSize int64, pageToken string) ([]*m.Message, string, error)
 }
 
+type HistoryFetcherIF interface {
+	FetchProfileHistoryID(ctx context.Context, svc *gmail.Service) (uint64, error)
+	FetchAddedMsgIdsSince(ctx context.Context, svc *gmail.Service, startHistoryID uint64) ([]string, uint64, error)
+}
+
+type AttachmentFetcherIF interface {
+	FetchAttachment(ctx context.Context, svc *gmail.Service, messageID, attachmentID string) ([]byte, error)
+}
+
 type MessageIF interface {
 	MsgIDFetcherIF
 	MsgDetailFetcherIF
 	MessageFetcherIF
+	HistoryFetcherIF
+	AttachmentFetcherIF
 }
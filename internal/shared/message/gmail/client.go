@@ -17,7 +17,7 @@ func NewGmailClientWithRefresh(ctx context.Context, encRefresh []byte) (*gmail.S
 	if len(encRefresh) == 0 {
 		return nil, errors.New("empty refresh token")
 	}
-	rt, err := crypto.DecryptFromBytes(encRefresh)
+	rt, err := crypto.DecryptFromBytesContext(ctx, encRefresh)
 	if err != nil {
 		return nil, err
 	}
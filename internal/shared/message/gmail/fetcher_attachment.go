@@ -0,0 +1,31 @@
+package gmail
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"shakehandz-api/internal/shared/retry"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// FetchAttachment は添付ファイルの本体を取得し、デコードしたバイト列を返す
+func (fetcher *GmailMsgFetcher) FetchAttachment(ctx context.Context, svc *gmail.Service, messageID, attachmentID string) ([]byte, error) {
+	body, err := retry.DoValue(ctx, retry.DefaultPolicy, "Gmail 添付ファイル取得", func(ctx context.Context) (*gmail.MessagePartBody, error) {
+		return svc.Users.Messages.Attachments.Get("me", messageID, attachmentID).Context(ctx).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Gmail APIはURL-safeなBase64で返却する（パディング有無の両方に対応）
+	data, err := base64.URLEncoding.DecodeString(body.Data)
+	if err != nil {
+		if raw, rawErr := base64.RawURLEncoding.DecodeString(body.Data); rawErr == nil {
+			return raw, nil
+		}
+		return nil, fmt.Errorf("decode attachment: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,72 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"shakehandz-api/internal/shared/retry"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// ErrHistoryExpired は指定したhistoryIdが古く、差分取得できない場合のエラー
+var ErrHistoryExpired = errors.New("gmail: history id expired")
+
+// FetchProfileHistoryID はメールボックスの現在のhistoryIdを取得する
+func (fetcher *GmailMsgFetcher) FetchProfileHistoryID(ctx context.Context, svc *gmail.Service) (uint64, error) {
+	profile, err := retry.DoValue(ctx, retry.DefaultPolicy, "Gmail プロフィール取得", func(ctx context.Context) (*gmail.Profile, error) {
+		return svc.Users.GetProfile("me").Context(ctx).Do()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return profile.HistoryId, nil
+}
+
+// FetchAddedMsgIdsSince はstartHistoryID以降に追加されたメッセージIDと、最新のhistoryIdを取得する
+func (fetcher *GmailMsgFetcher) FetchAddedMsgIdsSince(ctx context.Context, svc *gmail.Service, startHistoryID uint64) ([]string, uint64, error) {
+	var ids []string
+	seen := make(map[string]bool)
+	latestHistoryID := startHistoryID
+	pageToken := ""
+
+	for {
+		call := svc.Users.History.List("me").StartHistoryId(startHistoryID).HistoryTypes("messageAdded")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		res, err := retry.DoValue(ctx, retry.DefaultPolicy, "Gmail History取得", func(ctx context.Context) (*gmail.ListHistoryResponse, error) {
+			return call.Context(ctx).Do()
+		})
+		if err != nil {
+			// historyIdの保持期間を過ぎている場合は404が返却される
+			var gerr *googleapi.Error
+			if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+				return nil, 0, ErrHistoryExpired
+			}
+			return nil, 0, err
+		}
+
+		for _, h := range res.History {
+			for _, added := range h.MessagesAdded {
+				if added.Message == nil || seen[added.Message.Id] {
+					continue
+				}
+				seen[added.Message.Id] = true
+				ids = append(ids, added.Message.Id)
+			}
+		}
+		if res.HistoryId > latestHistoryID {
+			latestHistoryID = res.HistoryId
+		}
+
+		pageToken = res.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+
+	return ids, latestHistoryID, nil
+}
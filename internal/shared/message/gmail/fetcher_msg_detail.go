@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	msg "shakehandz-api/internal/shared/message"
+	"shakehandz-api/internal/shared/retry"
 
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
@@ -22,16 +23,21 @@ func (fetcher *GmailMsgFetcher) FetchMsgDetails(ctx context.Context, srv *gmail.
 	// 同時に実行するリクエスト数を10に制限
 	sem := semaphore.NewWeighted(10)
 
+	// キャンセル時はセマフォの取得に失敗するため、途中までの結果は返さずエラーとする
+	var acquireErr error
 	for _, m := range messages {
 		mid := m.Id
 		if err := sem.Acquire(ctx, 1); err != nil {
 			log.Printf("Failed to acquire semaphore: %v", err)
+			acquireErr = err
 			break
 		}
 		g.Go(func() error {
 			defer sem.Release(1)
 
-			msg, err := srv.Users.Messages.Get("me", mid).Format("full").Do()
+			msg, err := retry.DoValue(ctx, retry.DefaultPolicy, "Gmail メッセージ取得", func(ctx context.Context) (*gmail.Message, error) {
+				return srv.Users.Messages.Get("me", mid).Format("full").Context(ctx).Do()
+			})
 			if err != nil {
 				return err
 			}
@@ -49,6 +55,9 @@ func (fetcher *GmailMsgFetcher) FetchMsgDetails(ctx context.Context, srv *gmail.
 		log.Printf("fetcher: detail fetch error: %v", err)
 		return nil, err
 	}
+	if acquireErr != nil {
+		return nil, acquireErr
+	}
 	// 日付降順
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Date > result[j].Date
@@ -1,14 +1,20 @@
 package gmail
 
 import (
+	"context"
+
+	"shakehandz-api/internal/shared/retry"
+
 	"google.golang.org/api/gmail/v1"
 )
 
-func (fetcher *GmailMsgFetcher) FetchMsgIds(svc *gmail.Service, query string, max int64) ([]*gmail.Message, error) {
+func (fetcher *GmailMsgFetcher) FetchMsgIds(ctx context.Context, svc *gmail.Service, query string, max int64) ([]*gmail.Message, error) {
 	if max <= 0 {
 		max = 10
 	}
-	list, err := svc.Users.Messages.List("me").MaxResults(max).Q(query).Do()
+	list, err := retry.DoValue(ctx, retry.DefaultPolicy, "Gmail メッセージ一覧取得", func(ctx context.Context) (*gmail.ListMessagesResponse, error) {
+		return svc.Users.Messages.List("me").MaxResults(max).Q(query).Context(ctx).Do()
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -19,7 +25,7 @@ func (fetcher *GmailMsgFetcher) FetchMsgIds(svc *gmail.Service, query string, ma
 }
 
 // ページング版（svc使い回し）
-func (fetcher *GmailMsgFetcher) FetchMsgIdsWithPaging(svc *gmail.Service, query string, pageSize int64, pageToken string) ([]*gmail.Message, string, error) {
+func (fetcher *GmailMsgFetcher) FetchMsgIdsWithPaging(ctx context.Context, svc *gmail.Service, query string, pageSize int64, pageToken string) ([]*gmail.Message, string, error) {
 	if pageSize <= 0 {
 		pageSize = 50
 	}
@@ -27,7 +33,9 @@ func (fetcher *GmailMsgFetcher) FetchMsgIdsWithPaging(svc *gmail.Service, query
 	if pageToken != "" {
 		call = call.PageToken(pageToken)
 	}
-	list, err := call.Do()
+	list, err := retry.DoValue(ctx, retry.DefaultPolicy, "Gmail メッセージ一覧取得", func(ctx context.Context) (*gmail.ListMessagesResponse, error) {
+		return call.Context(ctx).Do()
+	})
 	if err != nil {
 		return nil, "", err
 	}
@@ -0,0 +1,68 @@
+// Package ratelimit は、ユーザーなどのキーごとのトークンバケットを提供します。
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// KeyedLimiter はキーごとに独立したトークンバケットを管理します。
+type KeyedLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	limit    rate.Limit
+	burst    int
+}
+
+// NewKeyedLimiter は1分あたりperMinute回、最大burst回まで連続で許可するリミッターを生成します。
+// perMinuteが0以下の場合は制限しません。
+func NewKeyedLimiter(perMinute float64, burst int) *KeyedLimiter {
+	limit := rate.Inf
+	if perMinute > 0 {
+		limit = rate.Limit(perMinute / 60)
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &KeyedLimiter{
+		limiters: make(map[string]*rate.Limiter),
+		limit:    limit,
+		burst:    burst,
+	}
+}
+
+// NewKeyedLimiterFromEnv は環境変数 <prefix>_PER_MINUTE, <prefix>_BURST から設定を読み込みます。
+func NewKeyedLimiterFromEnv(prefix string, defPerMinute float64, defBurst int) *KeyedLimiter {
+	perMinute := defPerMinute
+	if v, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv(prefix+"_PER_MINUTE")), 64); err == nil {
+		perMinute = v
+	}
+	burst := defBurst
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(prefix + "_BURST"))); err == nil && v > 0 {
+		burst = v
+	}
+	return NewKeyedLimiter(perMinute, burst)
+}
+
+// For はキーに対応するリミッターを返します。
+func (k *KeyedLimiter) For(key string) *rate.Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	l, ok := k.limiters[key]
+	if !ok {
+		l = rate.NewLimiter(k.limit, k.burst)
+		k.limiters[key] = l
+	}
+	return l
+}
+
+// Wait はキーのバケットからトークンを取得できるまで待機します。
+func (k *KeyedLimiter) Wait(ctx context.Context, key string) error {
+	return k.For(key).Wait(ctx)
+}
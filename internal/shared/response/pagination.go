@@ -0,0 +1,19 @@
+package response
+
+// PaginationInfo は一覧取得APIのページング情報です。
+type PaginationInfo struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"total_pages"`
+}
+
+// NewPaginationInfo は総件数からページ数を計算してページング情報を返します。
+func NewPaginationInfo(page, limit int, total int64) PaginationInfo {
+	return PaginationInfo{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+}
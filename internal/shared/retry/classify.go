@@ -0,0 +1,197 @@
+// Package retry は、Google API（Gmail/Gemini）などの外部呼び出しのエラー分類と、
+// 指数バックオフ＋ジッターによるリトライを提供します。
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/googleapis/gax-go/v2/apierror"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Class はエラーの分類です。
+type Class string
+
+const (
+	// 短時間の呼び出し過多。待てば回復する
+	ClassRateLimit Class = "rate_limit"
+	// 日次などの割り当て超過。すぐには回復しない
+	ClassQuota Class = "quota"
+	// サーバー側・ネットワークの一時的な障害
+	ClassTransient Class = "transient"
+	// refresh_tokenの失効・取り消し。ユーザーの再認証が必要
+	ClassAuthRevoked Class = "auth_revoked"
+	// リトライしても成功しないエラー
+	ClassPermanent Class = "permanent"
+)
+
+// Retryable はその分類のエラーを呼び出し単位でリトライすべきかを返します。
+func (c Class) Retryable() bool {
+	return c == ClassRateLimit || c == ClassTransient
+}
+
+// HTTPStatusError はHTTPステータスコードを持つエラーが満たすインターフェースです。
+// Google以外のAPIクライアントのエラーも分類できるようにします。
+type HTTPStatusError interface {
+	HTTPStatus() int
+}
+
+// quotaの超過を表すreason（googleapi）
+var quotaReasons = map[string]bool{
+	"quotaExceeded":      true,
+	"dailyLimitExceeded": true,
+}
+
+// レート制限を表すreason（googleapi）
+var rateLimitReasons = map[string]bool{
+	"rateLimitExceeded":     true,
+	"userRateLimitExceeded": true,
+}
+
+// Classify はエラーを分類します。nilの場合は空文字を返します。
+func Classify(err error) Class {
+	if err == nil {
+		return ""
+	}
+
+	// キャンセルはリトライしない
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ClassPermanent
+	}
+
+	// OAuth2のトークン更新エラー
+	if IsInvalidGrant(err) {
+		return ClassAuthRevoked
+	}
+	var rerr *oauth2.RetrieveError
+	if errors.As(err, &rerr) {
+		if rerr.Response != nil {
+			return classifyHTTP(rerr.Response.StatusCode, "")
+		}
+		return ClassPermanent
+	}
+
+	// REST（Gmail API）
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		for _, item := range gerr.Errors {
+			if quotaReasons[item.Reason] {
+				return ClassQuota
+			}
+			if rateLimitReasons[item.Reason] {
+				return ClassRateLimit
+			}
+		}
+		return classifyHTTP(gerr.Code, gerr.Message)
+	}
+
+	// gRPC（Gemini API）
+	var aerr *apierror.APIError
+	if errors.As(err, &aerr) {
+		if aerr.Reason() == "RATE_LIMIT_EXCEEDED" {
+			return ClassRateLimit
+		}
+		if code := aerr.HTTPCode(); code > 0 {
+			return classifyHTTP(code, aerr.Error())
+		}
+		if st := aerr.GRPCStatus(); st != nil {
+			return classifyGRPC(st.Code(), st.Message())
+		}
+	}
+	if st, ok := status.FromError(err); ok && st.Code() != codes.Unknown {
+		return classifyGRPC(st.Code(), st.Message())
+	}
+
+	var herr HTTPStatusError
+	if errors.As(err, &herr) {
+		return classifyHTTP(herr.HTTPStatus(), err.Error())
+	}
+
+	// ネットワークエラー
+	var nerr net.Error
+	if errors.As(err, &nerr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return ClassTransient
+	}
+
+	return ClassPermanent
+}
+
+// IsInvalidGrant はrefresh_tokenが失効・取り消しされたこと（invalid_grant）によるエラーかを返します。
+// gRPC経由（Gemini）ではRetrieveErrorが文字列化されて返るため、メッセージでも判定する
+func IsInvalidGrant(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rerr *oauth2.RetrieveError
+	if errors.As(err, &rerr) {
+		return rerr.ErrorCode == "invalid_grant" || strings.Contains(string(rerr.Body), "invalid_grant")
+	}
+	return strings.Contains(err.Error(), "invalid_grant")
+}
+
+func classifyHTTP(code int, message string) Class {
+	switch {
+	case code == http.StatusTooManyRequests:
+		if isQuotaMessage(message) {
+			return ClassQuota
+		}
+		return ClassRateLimit
+	case code == http.StatusUnauthorized:
+		return ClassAuthRevoked
+	case code == http.StatusRequestTimeout, code >= 500:
+		return ClassTransient
+	default:
+		return ClassPermanent
+	}
+}
+
+func classifyGRPC(code codes.Code, message string) Class {
+	switch code {
+	case codes.ResourceExhausted:
+		if isQuotaMessage(message) {
+			return ClassQuota
+		}
+		return ClassRateLimit
+	case codes.Unauthenticated:
+		return ClassAuthRevoked
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Aborted:
+		return ClassTransient
+	default:
+		return ClassPermanent
+	}
+}
+
+// 日次の割り当て超過はメッセージでしか判別できない場合がある
+func isQuotaMessage(message string) bool {
+	m := strings.ToLower(message)
+	return strings.Contains(m, "quota") && (strings.Contains(m, "per day") || strings.Contains(m, "daily"))
+}
+
+// RetryAfter はエラーに含まれるRetry-Afterヘッダーの待機時間を返します。
+func RetryAfter(err error) (time.Duration, bool) {
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) || gerr.Header == nil {
+		return 0, false
+	}
+	v := gerr.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
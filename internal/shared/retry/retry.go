@@ -0,0 +1,102 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy はリトライの設定です。
+type Policy struct {
+	// 最初の呼び出しを含む最大試行回数
+	MaxAttempts int
+	// 初回リトライの待機時間の上限。以降は試行ごとに倍増する
+	BaseDelay time.Duration
+	// 待機時間の上限
+	MaxDelay time.Duration
+}
+
+// DefaultPolicy は外部API呼び出しの標準的なリトライ設定です。
+var DefaultPolicy = Policy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// Error はリトライを諦めたエラーです。分類と試行回数を保持します。
+type Error struct {
+	Class    Class
+	Attempts int
+	Err      error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (class=%s, attempts=%d)", e.Err.Error(), e.Class, e.Attempts)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Do はfnを実行し、リトライ可能なエラーの場合は指数バックオフ＋ジッターで再実行します。
+// リトライ不可のエラー、または試行回数を使い切った場合は *Error を返します。
+func Do(ctx context.Context, p Policy, name string, fn func(ctx context.Context) error) error {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		class := Classify(err)
+		if !class.Retryable() || attempt >= p.MaxAttempts || ctx.Err() != nil {
+			return &Error{Class: class, Attempts: attempt, Err: err}
+		}
+
+		delay := p.backoff(attempt)
+		if d, ok := RetryAfter(err); ok && d > delay {
+			delay = d
+		}
+		log.Printf("%s: %sのため%s後にリトライします（%d/%d）: %v", name, class, delay.Round(time.Millisecond), attempt, p.MaxAttempts, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &Error{Class: class, Attempts: attempt, Err: err}
+		case <-timer.C:
+		}
+	}
+}
+
+// DoValue は値を返す関数版の Do です。
+func DoValue[T any](ctx context.Context, p Policy, name string, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := Do(ctx, p, name, func(ctx context.Context) error {
+		v, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		result = v
+		return nil
+	})
+	return result, err
+}
+
+// backoff はattempt回目の失敗後の待機時間を返す（full jitter）
+func (p Policy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay
+	for i := 1; i < attempt && ceiling < p.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	if p.MaxDelay > 0 && ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling))) + 1
+}
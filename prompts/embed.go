@@ -4,3 +4,9 @@ import _ "embed"
 
 //go:embed human-resources-instruction.txt
 var HRInstruction string
+
+//go:embed project-instruction.txt
+var ProjectInstruction string
+
+//go:embed match-proposal-instruction.txt
+var MatchProposalInstruction string